@@ -0,0 +1,59 @@
+package silk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"math/bits"
+)
+
+// aiffHeaderLen FORM(12) + COMM(26) + SSND 头(16)
+const aiffHeaderLen = 54
+
+// SilkToAiff 将silk文件的reader转换为AIFF数据以供 macOS/专业音频工具使用
+func SilkToAiff(src io.Reader, opts WavOptions) (io.Reader, error) {
+	opts = opts.withDefaults()
+	decoder := newDecoder()
+	data, info, err := decoder.DecodeWithInfo(src, DecodeOptions{SampleRate: opts.SampleRate})
+	if err != nil {
+		return nil, err
+	}
+	return bytes.NewReader(pcmToAiff(data, opts.Channels, info.EffectiveSampleRate)), nil
+}
+
+// pcmToAiff 将小端序 16bit pcm 包装为 AIFF
+// AIFF 为大端序, 写入采样时逐个交换字节
+func pcmToAiff(pcm []byte, numchannel int, samplerate int) []byte {
+	dataLen := len(pcm) &^ 1 // 只保留完整的 int16 采样
+	out := make([]byte, aiffHeaderLen+dataLen)
+	// FORM chunk
+	copy(out[0:4], "FORM")
+	binary.BigEndian.PutUint32(out[4:8], uint32(aiffHeaderLen-8+dataLen))
+	copy(out[8:12], "AIFF")
+	// COMM chunk
+	copy(out[12:16], "COMM")
+	binary.BigEndian.PutUint32(out[16:20], 18)
+	binary.BigEndian.PutUint16(out[20:22], uint16(numchannel))
+	binary.BigEndian.PutUint32(out[22:26], uint32(dataLen/(2*numchannel)))
+	binary.BigEndian.PutUint16(out[26:28], 16) // bits per sample
+	putExtended(out[28:38], samplerate)
+	// SSND chunk, offset 和 blockSize 均为 0
+	copy(out[38:42], "SSND")
+	binary.BigEndian.PutUint32(out[42:46], uint32(8+dataLen))
+	data := out[aiffHeaderLen:]
+	for i := 0; i < dataLen; i += 2 {
+		data[i] = pcm[i+1]
+		data[i+1] = pcm[i]
+	}
+	return out
+}
+
+// putExtended 将采样率写为 80 位 IEEE 754 扩展精度浮点数(COMM chunk 要求)
+func putExtended(b []byte, v int) {
+	if v <= 0 {
+		return
+	}
+	e := bits.Len64(uint64(v)) - 1
+	binary.BigEndian.PutUint16(b[0:2], uint16(16383+e))
+	binary.BigEndian.PutUint64(b[2:10], uint64(v)<<(63-e))
+}
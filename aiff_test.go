@@ -0,0 +1,121 @@
+package silk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+)
+
+// aiffFile 测试中解析出的 AIFF 内容
+type aiffFile struct {
+	channels    int
+	frames      int
+	bits        int
+	sampleRate  int
+	offset      int
+	blockSize   int
+	samples     []int16
+	chunkLayout []string
+}
+
+// parseAiff 按 chunk 解析 AIFF, 校验 FORM 长度与实际数据一致
+func parseAiff(t *testing.T, data []byte) aiffFile {
+	t.Helper()
+	if len(data) < 12 || string(data[0:4]) != "FORM" || string(data[8:12]) != "AIFF" {
+		t.Fatalf("not a FORM/AIFF file: % x", data[:12])
+	}
+	if size := binary.BigEndian.Uint32(data[4:8]); int(size) != len(data)-8 {
+		t.Fatalf("FORM size = %d, want %d", size, len(data)-8)
+	}
+	var f aiffFile
+	for rest := data[12:]; len(rest) > 0; {
+		if len(rest) < 8 {
+			t.Fatalf("%d trailing bytes after the last chunk", len(rest))
+		}
+		id, size := string(rest[0:4]), int(binary.BigEndian.Uint32(rest[4:8]))
+		if 8+size > len(rest) {
+			t.Fatalf("chunk %s size %d exceeds the remaining %d bytes", id, size, len(rest)-8)
+		}
+		body := rest[8 : 8+size]
+		f.chunkLayout = append(f.chunkLayout, id)
+		switch id {
+		case "COMM":
+			f.channels = int(binary.BigEndian.Uint16(body[0:2]))
+			f.frames = int(binary.BigEndian.Uint32(body[2:6]))
+			f.bits = int(binary.BigEndian.Uint16(body[6:8]))
+			exp := int(binary.BigEndian.Uint16(body[8:10])) - 16383
+			mantissa := binary.BigEndian.Uint64(body[10:18])
+			f.sampleRate = int(mantissa >> uint(63-exp))
+		case "SSND":
+			f.offset = int(binary.BigEndian.Uint32(body[0:4]))
+			f.blockSize = int(binary.BigEndian.Uint32(body[4:8]))
+			for i := 8 + f.offset; i+1 < len(body); i += 2 {
+				f.samples = append(f.samples, int16(binary.BigEndian.Uint16(body[i:])))
+			}
+		}
+		rest = rest[8+size+size&1:]
+	}
+	return f
+}
+
+func TestPcmToAiff(t *testing.T) {
+	want := []int16{0, 1, -1, 32767, -32768, 0x1234}
+	pcm := make([]byte, 2*len(want))
+	for i, v := range want {
+		binary.LittleEndian.PutUint16(pcm[2*i:], uint16(v))
+	}
+	f := parseAiff(t, pcmToAiff(pcm, 1, 24000))
+	if got := f.chunkLayout; len(got) != 2 || got[0] != "COMM" || got[1] != "SSND" {
+		t.Fatalf("chunks = %v, want [COMM SSND]", got)
+	}
+	if f.channels != 1 || f.bits != 16 || f.sampleRate != 24000 || f.frames != len(want) {
+		t.Fatalf("COMM = %d ch, %d bit, %d Hz, %d frames", f.channels, f.bits, f.sampleRate, f.frames)
+	}
+	if f.offset != 0 || f.blockSize != 0 {
+		t.Fatalf("SSND offset/blockSize = %d/%d, want 0/0", f.offset, f.blockSize)
+	}
+	if len(f.samples) != len(want) {
+		t.Fatalf("got %d samples, want %d", len(f.samples), len(want))
+	}
+	for i := range want {
+		if f.samples[i] != want[i] {
+			t.Fatalf("sample %d = %d, want %d", i, f.samples[i], want[i])
+		}
+	}
+}
+
+func TestPcmToAiffOddLength(t *testing.T) {
+	f := parseAiff(t, pcmToAiff([]byte{1, 0, 2}, 1, 16000))
+	if f.frames != 1 || len(f.samples) != 1 || f.samples[0] != 1 {
+		t.Fatalf("frames = %d, samples = %v, want the single complete sample", f.frames, f.samples)
+	}
+}
+
+func TestPcmToAiffSampleRates(t *testing.T) {
+	for _, rate := range []int{8000, 12000, 16000, 24000, 44100, 48000} {
+		if f := parseAiff(t, pcmToAiff(make([]byte, 4), 1, rate)); f.sampleRate != rate {
+			t.Errorf("sample rate %d round-tripped as %d", rate, f.sampleRate)
+		}
+	}
+}
+
+func TestSilkToAiff(t *testing.T) {
+	useFake(t, newFakeNative())
+	stream := withFooter(buildStream(nil, payloads(3, 30)...))
+	r, err := SilkToAiff(bytes.NewReader(stream), WavOptions{SampleRate: 24000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f := parseAiff(t, data)
+	if f.sampleRate != 24000 || f.frames != 3*480 {
+		t.Fatalf("got %d Hz, %d frames, want 24000 Hz, %d frames", f.sampleRate, f.frames, 3*480)
+	}
+	if f.samples[0] != 'a'*100 || f.samples[480] != 'b'*100 {
+		t.Fatalf("samples = %d, %d, want %d, %d", f.samples[0], f.samples[480], 'a'*100, 'b'*100)
+	}
+}
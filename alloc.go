@@ -0,0 +1,29 @@
+package silk
+
+// alloc 按 o.Alloc 分配 n 字节, 未设置时使用 make
+func (o DecodeOptions) alloc(n int) []byte {
+	if o.Alloc != nil {
+		return o.Alloc(n)[:n]
+	}
+	return make([]byte, n)
+}
+
+// allocBuffer 使用 DecodeOptions.Alloc 扩容的 io.Writer, 收集整段解码的 pcm
+type allocBuffer struct {
+	opts DecodeOptions
+	buf  []byte
+}
+
+func (b *allocBuffer) Write(p []byte) (int, error) {
+	if len(b.buf)+len(p) > cap(b.buf) {
+		size := 2 * cap(b.buf)
+		if size < len(b.buf)+len(p) {
+			size = len(b.buf) + len(p)
+		}
+		grown := b.opts.alloc(size)[:len(b.buf)]
+		copy(grown, b.buf)
+		b.buf = grown
+	}
+	b.buf = append(b.buf, p...)
+	return len(p), nil
+}
@@ -0,0 +1,86 @@
+package silk
+
+import (
+	"bytes"
+	"testing"
+)
+
+// countingAlloc 记录每次分配的长度和返回的切片
+type countingAlloc struct {
+	sizes []int
+	bufs  [][]byte
+}
+
+func (a *countingAlloc) alloc(n int) []byte {
+	buf := make([]byte, n, n+16) // 多给一些容量, 确认调用方按 n 截断
+	a.sizes = append(a.sizes, n)
+	a.bufs = append(a.bufs, buf)
+	return buf
+}
+
+// owns 返回 p 是否位于 a 分配的某个切片中
+func (a *countingAlloc) owns(p []byte) bool {
+	for _, buf := range a.bufs {
+		if len(p) > 0 && len(buf) > 0 && &buf[:cap(buf)][cap(buf)-1] == &p[:cap(p)][cap(p)-1] {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *countingAlloc) has(n int) bool {
+	for _, size := range a.sizes {
+		if size == n {
+			return true
+		}
+	}
+	return false
+}
+
+func TestDecodeAlloc(t *testing.T) {
+	a := &countingAlloc{}
+	stream := withFooter(buildStream(nil, payloads(20, 30)...))
+	pcm, _, err := decodeFake(t, stream, DecodeOptions{Alloc: a.alloc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pcm) != 20*640 {
+		t.Fatalf("decoded %d bytes, want %d", len(pcm), 20*640)
+	}
+	// 输出 pcm 和解码用的 in/buf 都由 Alloc 分配
+	if !a.owns(pcm) {
+		t.Error("output pcm was not allocated by Alloc")
+	}
+	frameSize := (FRAME_LENGTH_MS * MAX_API_FS_KHZ) << 1
+	if !a.has(1024) || !a.has(frameSize*2) {
+		t.Errorf("allocations %v do not include the in (1024) and buf (%d) scratch buffers", a.sizes, frameSize*2)
+	}
+}
+
+func TestDecodeAllocSameOutput(t *testing.T) {
+	stream := withFooter(buildStream(nil, payloads(20, 30)...))
+	want, _, err := decodeFake(t, stream, DecodeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a := &countingAlloc{}
+	got, _, err := decodeFake(t, stream, DecodeOptions{Alloc: a.alloc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatal("decoding with Alloc changed the output")
+	}
+}
+
+func TestDecodeAllocAbandonOnCancel(t *testing.T) {
+	a := &countingAlloc{}
+	stream := withFooter(buildStream(nil, payloads(3, 30)...))
+	if _, _, err := decodeFake(t, stream, DecodeOptions{Alloc: a.alloc, AbandonOnCancel: true}); err != nil {
+		t.Fatal(err)
+	}
+	// 被放弃的 dll 调用可能在返回后继续写入, 此时 in/buf 不使用 Alloc
+	if a.has(1024) {
+		t.Errorf("allocations %v include the scratch buffers with AbandonOnCancel", a.sizes)
+	}
+}
@@ -0,0 +1,65 @@
+package silk
+
+import (
+	"debug/pe"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// machineArch PE 文件头中的 Machine 与 GOARCH 的对应
+var machineArch = map[uint16]string{
+	pe.IMAGE_FILE_MACHINE_I386:  "386",
+	pe.IMAGE_FILE_MACHINE_AMD64: "amd64",
+	pe.IMAGE_FILE_MACHINE_ARMNT: "arm",
+	pe.IMAGE_FILE_MACHINE_ARM64: "arm64",
+}
+
+// DLLArch 返回已加载 dll 的架构(GOARCH 写法, 如 "386"/"amd64"), 无法识别时返回空字符串
+func (s *silk) DLLArch() string {
+	return machineArch[s.machine]
+}
+
+// loadDLL 加载前先解析 dll 的 PE 头, 架构与当前进程不一致时返回 ErrDLLArchMismatch,
+// 而不是 LoadDLL 含糊的错误 193(ERROR_BAD_EXE_FORMAT)
+func (s *silk) loadDLL(name string) (*dllModule, error) {
+	if file := findDLL(name); file != "" {
+		f, err := pe.Open(file)
+		if err == nil {
+			s.machine = f.FileHeader.Machine
+			f.Close()
+			if arch := machineArch[s.machine]; arch != runtime.GOARCH {
+				if arch == "" {
+					arch = fmt.Sprintf("machine 0x%x", s.machine)
+				}
+				return nil, fmt.Errorf("%w: %s is %s, process is %s", ErrDLLArchMismatch, file, arch, runtime.GOARCH)
+			}
+		}
+	}
+	return loadSystemDLL(name)
+}
+
+// findDLL 返回 LoadDLL(name) 大致会加载的文件: 带路径时即为 name,
+// 否则依次查找程序目录、当前目录和 PATH; 找不到时返回空字符串
+func findDLL(name string) string {
+	if strings.ContainsAny(name, `/\:`) {
+		return name
+	}
+	var dirs []string
+	if exe, err := os.Executable(); err == nil {
+		dirs = append(dirs, filepath.Dir(exe))
+	}
+	if wd, err := os.Getwd(); err == nil {
+		dirs = append(dirs, wd)
+	}
+	dirs = append(dirs, filepath.SplitList(os.Getenv("PATH"))...)
+	for _, dir := range dirs {
+		file := filepath.Join(dir, name)
+		if fi, err := os.Stat(file); err == nil && !fi.IsDir() {
+			return file
+		}
+	}
+	return ""
+}
@@ -0,0 +1,7 @@
+//go:build windows && arm64
+
+package silk
+
+// dllArchHint 加载 dll 得到 ERROR_BAD_EXE_FORMAT 时附加的说明
+// arm64 进程无法加载 x64/x86 的 dll, 仓库自带的 bin/dllsilk.dll 为 x64 版本
+const dllArchHint = "windows/arm64 requires an ARM64 (or ARM64EC) build of dllsilk.dll, bin/dllsilk.dll is x64"
@@ -0,0 +1,6 @@
+//go:build !(windows && arm64)
+
+package silk
+
+// dllArchHint 加载 dll 得到 ERROR_BAD_EXE_FORMAT 时附加的说明
+const dllArchHint = ""
@@ -0,0 +1,132 @@
+package silk
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// ArchiveOptions DecodeArchive 的参数
+type ArchiveOptions struct {
+	Decode DecodeOptions // 每个条目的解码参数
+	Wav    bool          // 结果为 wav 文件而不是 pcm
+	// ContinueOnError 某个条目解码失败时跳过它继续处理其他条目, 返回已成功的结果和汇总的错误;
+	// 为 false 时遇到第一个失败的条目即返回
+	ContinueOnError bool
+}
+
+// DecodeArchive 解码 zip 或 tar 压缩包中所有扩展名为 .silk 的条目(不区分大小写), 返回条目名到 pcm(或 wav)的映射
+// format 为 "zip"、"tar" 或 "tar.gz"/"tgz"; zip 需要随机读取, 会先把 src 全部读入内存
+func DecodeArchive(src io.Reader, format string, opts ArchiveOptions) (map[string][]byte, error) {
+	if opts.Wav {
+		if err := opts.Decode.requireLinear(); err != nil {
+			return nil, err
+		}
+	}
+	result := make(map[string][]byte)
+	var failed, total int
+	var firstErr error
+	decode := func(name string, r io.Reader) error {
+		total++
+		data, err := opts.decode(r)
+		if err == nil {
+			result[name] = data
+			return nil
+		}
+		err = fmt.Errorf("failed to decode %s: %w", name, err)
+		if !opts.ContinueOnError {
+			return err
+		}
+		logger.Warn("skip archive entry: %+v", err)
+		if failed++; firstErr == nil {
+			firstErr = err
+		}
+		return nil
+	}
+	var err error
+	switch format {
+	case "zip":
+		err = walkZip(src, decode)
+	case "tar":
+		err = walkTar(src, decode)
+	case "tar.gz", "tgz":
+		var gz *gzip.Reader
+		if gz, err = gzip.NewReader(src); err == nil {
+			err = walkTar(gz, decode)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported archive format %q", format)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if firstErr != nil {
+		return result, fmt.Errorf("%d of %d archive entries failed, first: %w", failed, total, firstErr)
+	}
+	return result, nil
+}
+
+// decode 按 o 解码一个条目
+func (o ArchiveOptions) decode(r io.Reader) ([]byte, error) {
+	pcm, info, err := newDecoder().DecodeWithInfo(r, o.Decode)
+	if err != nil || !o.Wav {
+		return pcm, err
+	}
+	wav, _ := PCMToWav(pcm, WavOptions{SampleRate: o.Decode.decodedRate(info)})
+	return wav, nil
+}
+
+// isSilkEntry 判断压缩包中的条目名是否为 silk 文件
+func isSilkEntry(name string) bool {
+	return strings.EqualFold(path.Ext(name), ".silk")
+}
+
+func walkZip(src io.Reader, fn func(name string, r io.Reader) error) error {
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return fmt.Errorf("failed to read zip: %w", err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return fmt.Errorf("failed to open zip: %w", err)
+	}
+	for _, f := range zr.File {
+		if f.FileInfo().IsDir() || !isSilkEntry(f.Name) {
+			continue
+		}
+		r, err := f.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open zip entry %s: %w", f.Name, err)
+		}
+		err = fn(f.Name, r)
+		r.Close()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func walkTar(src io.Reader, fn func(name string, r io.Reader) error) error {
+	tr := tar.NewReader(src)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read tar: %w", err)
+		}
+		if hdr.Typeflag != tar.TypeReg || !isSilkEntry(hdr.Name) {
+			continue
+		}
+		if err = fn(hdr.Name, tr); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package silk
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// archiveEntries 测试用压缩包的内容: 两个 silk 文件、一个非 silk 文件和一个目录
+func archiveEntries() map[string][]byte {
+	return map[string][]byte{
+		"voice/a.silk": buildStream(nil, payloads(2, 4)...),
+		"voice/B.SILK": buildStream(nil, payloads(3, 4)...),
+		"readme.txt":   []byte("not audio"),
+	}
+}
+
+func buildZip(t *testing.T, entries map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	if _, err := zw.Create("voice/"); err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range entries {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		w.Write(data)
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func buildTar(t *testing.T, entries map[string][]byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for name, data := range entries {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0o644, Size: int64(len(data)), Typeflag: tar.TypeReg}); err != nil {
+			t.Fatal(err)
+		}
+		tw.Write(data)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecodeArchiveZip(t *testing.T) {
+	f := newFakeNative()
+	useFake(t, f)
+	result, err := DecodeArchive(bytes.NewReader(buildZip(t, archiveEntries())), "zip", ArchiveOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.checkLeaks(t)
+	if len(result) != 2 || len(result["voice/a.silk"]) != 2*640 || len(result["voice/B.SILK"]) != 3*640 {
+		t.Fatalf("got %d entries: a %d bytes, B %d bytes", len(result), len(result["voice/a.silk"]), len(result["voice/B.SILK"]))
+	}
+	if got := firstSamples(result["voice/B.SILK"], 640); !equalSamples(got, wantFrames(3)) {
+		t.Fatalf("B.SILK frames %v, want %v", got, wantFrames(3))
+	}
+}
+
+func TestDecodeArchiveWav(t *testing.T) {
+	useFake(t, newFakeNative())
+	result, err := DecodeArchive(bytes.NewReader(buildTar(t, archiveEntries())), "tar", ArchiveOptions{Wav: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	format, data := parseWav(t, result["voice/a.silk"])
+	if format.sampleRate != 16000 || len(data) != 2*640 {
+		t.Fatalf("wav rate %d, %d data bytes", format.sampleRate, len(data))
+	}
+}
+
+func TestDecodeArchiveContinueOnError(t *testing.T) {
+	entries := archiveEntries()
+	entries["voice/bad.silk"] = []byte("#!SILK_V2 this is not silk")
+	archive := buildZip(t, entries)
+
+	f := newFakeNative()
+	useFake(t, f)
+	if _, err := DecodeArchive(bytes.NewReader(archive), "zip", ArchiveOptions{}); !errors.Is(err, ErrInvalidHeader) {
+		t.Fatalf("without ContinueOnError: err = %v, want ErrInvalidHeader", err)
+	}
+
+	result, err := DecodeArchive(bytes.NewReader(archive), "zip", ArchiveOptions{ContinueOnError: true})
+	if !errors.Is(err, ErrInvalidHeader) {
+		t.Fatalf("ContinueOnError: err = %v, want the failed entry's ErrInvalidHeader", err)
+	}
+	if len(result) != 2 || result["voice/bad.silk"] != nil {
+		t.Fatalf("ContinueOnError: got %d entries, want the 2 good ones", len(result))
+	}
+	f.checkLeaks(t)
+}
+
+func TestDecodeArchiveUnsupportedFormat(t *testing.T) {
+	if _, err := DecodeArchive(bytes.NewReader(nil), "rar", ArchiveOptions{}); err == nil {
+		t.Fatal("accepted an unsupported archive format")
+	}
+}
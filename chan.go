@@ -0,0 +1,73 @@
+package silk
+
+import (
+	"context"
+	"io"
+)
+
+// DecodeChan 在后台解码 src, 以 chunkBytes 字节为一块从第一个 channel 发出 pcm(最后一块可能更短),
+// 解码结束后关闭该 channel, 再从第二个 channel 发出唯一的结果(成功时为 nil)并关闭
+func DecodeChan(src io.Reader, chunkBytes int, opts DecodeOptions) (<-chan []byte, <-chan error) {
+	return DecodeChanContext(context.Background(), src, chunkBytes, opts)
+}
+
+// DecodeChanContext 同 DecodeChan, ctx 取消时停止解码和发送, 结果为 ctx.Err()
+// 消费方不再读取数据时应取消 ctx, 否则后台解码会阻塞在发送上
+func DecodeChanContext(ctx context.Context, src io.Reader, chunkBytes int, opts DecodeOptions) (<-chan []byte, <-chan error) {
+	if chunkBytes <= 0 {
+		chunkBytes = defaultReadBufferSize
+	}
+	data := make(chan []byte)
+	errc := make(chan error, 1)
+	go func() {
+		w := &chunkWriter{ctx: ctx, out: data, size: chunkBytes}
+		_, err := newDecoder().DecodeStreamInfo(ctx, w, src, opts)
+		if err == nil {
+			err = w.flush()
+		}
+		close(data)
+		errc <- err
+		close(errc)
+	}()
+	return data, errc
+}
+
+// chunkWriter 把写入的 pcm 切分为固定大小的块发送到 out
+type chunkWriter struct {
+	ctx  context.Context
+	out  chan<- []byte
+	size int
+	buf  []byte
+}
+
+func (w *chunkWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 {
+		if w.buf == nil {
+			w.buf = make([]byte, 0, w.size)
+		}
+		c := copy(w.buf[len(w.buf):w.size], p)
+		w.buf = w.buf[:len(w.buf)+c]
+		p = p[c:]
+		if len(w.buf) == w.size {
+			if err := w.flush(); err != nil {
+				return n - len(p), err
+			}
+		}
+	}
+	return n, nil
+}
+
+// flush 发送已缓存的数据, 每块使用新的切片, 接收方可以直接保留
+func (w *chunkWriter) flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	select {
+	case w.out <- w.buf:
+		w.buf = nil
+		return nil
+	case <-w.ctx.Done():
+		return w.ctx.Err()
+	}
+}
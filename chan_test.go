@@ -0,0 +1,59 @@
+package silk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDecodeChan(t *testing.T) {
+	f := newFakeNative()
+	useFake(t, f)
+	data, errc := DecodeChan(bytes.NewReader(withFooter(buildStream(nil, payloads(5, 4)...))), 1000, DecodeOptions{})
+	var pcm []byte
+	var sizes []int
+	for chunk := range data {
+		pcm = append(pcm, chunk...)
+		sizes = append(sizes, len(chunk))
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	f.checkLeaks(t)
+	// 5 帧共 3200 字节, 最后一块更短
+	if len(sizes) != 4 || sizes[0] != 1000 || sizes[3] != 200 {
+		t.Fatalf("chunk sizes %v, want 1000 1000 1000 200", sizes)
+	}
+	if !equalSamples(firstSamples(pcm, 640), wantFrames(5)) {
+		t.Fatalf("frames %v, want %v", firstSamples(pcm, 640), wantFrames(5))
+	}
+}
+
+func TestDecodeChanContextCancel(t *testing.T) {
+	f := newFakeNative()
+	useFake(t, f)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	data, errc := DecodeChanContext(ctx, bytes.NewReader(buildStream(nil, payloads(20, 4)...)), 640, DecodeOptions{})
+	if chunk := <-data; len(chunk) != 640 {
+		t.Fatalf("first chunk %d bytes, want 640", len(chunk))
+	}
+	// 取消后不再读取 data, 后台解码也不能阻塞在发送上
+	cancel()
+	select {
+	case err := <-errc:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("err = %v, want context.Canceled", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("decoding did not stop after cancel")
+	}
+	f.checkLeaks(t)
+	if f.calls >= 20 {
+		t.Fatalf("decoded all %d frames after cancel", f.calls)
+	}
+	for range data {
+	}
+}
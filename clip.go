@@ -0,0 +1,74 @@
+package silk
+
+import (
+	"io"
+	"time"
+)
+
+// Clip 解码结果, 小端序 16bit pcm 及其格式
+type Clip struct {
+	PCM      []byte
+	Rate     int
+	Channels int
+}
+
+// DecodeClip 按 opts 解码 src, 返回 Clip
+func DecodeClip(src io.Reader, opts DecodeOptions) (*Clip, error) {
+	opts = opts.withDefaults()
+	if err := opts.requireLinear(); err != nil {
+		return nil, err
+	}
+	pcm, info, err := newDecoder().DecodeWithInfo(src, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &Clip{PCM: pcm, Rate: opts.decodedRate(info), Channels: 1}, nil
+}
+
+// WAV 返回带 RIFF/WAVE 文件头的完整 wav 数据
+func (c *Clip) WAV() []byte {
+	return pcmToWav(c.PCM, c.Channels, c.Rate)
+}
+
+// Duration 返回时长
+func (c *Clip) Duration() time.Duration {
+	bytesPerSecond := int64(c.Rate) * int64(c.Channels) * 2
+	if bytesPerSecond <= 0 {
+		return 0
+	}
+	return time.Duration(int64(len(c.PCM)) * int64(time.Second) / bytesPerSecond)
+}
+
+// Samples 返回采样, 多声道时为交错排列
+func (c *Clip) Samples() []int16 {
+	return bytesToSamples(c.PCM)
+}
+
+// Resample 使用内置的线性插值重采样到 to, 返回新的 Clip, 原 Clip 不变
+// to 或 c.Rate 无效时返回 c 的拷贝
+func (c *Clip) Resample(to int) *Clip {
+	out := &Clip{Rate: c.Rate, Channels: c.Channels}
+	if to <= 0 || c.Rate <= 0 || c.Channels <= 0 || to == c.Rate {
+		out.PCM = append([]byte(nil), c.PCM...)
+		return out
+	}
+	samples := c.Samples()
+	frames := len(samples) / c.Channels
+	var resampled []int16
+	for ch := 0; ch < c.Channels; ch++ {
+		channel := make([]int16, frames)
+		for i := range channel {
+			channel[i] = samples[i*c.Channels+ch]
+		}
+		channel, _ = linearResampler{}.Resample(channel, c.Rate, to)
+		if resampled == nil {
+			resampled = make([]int16, len(channel)*c.Channels)
+		}
+		for i, v := range channel {
+			resampled[i*c.Channels+ch] = v
+		}
+	}
+	out.PCM = samplesToBytes(resampled)
+	out.Rate = to
+	return out
+}
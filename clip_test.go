@@ -0,0 +1,66 @@
+package silk
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestDecodeClip(t *testing.T) {
+	useFake(t, newFakeNative())
+	stream := withFooter(buildStream(nil, payloads(5, 4)...))
+	c, err := DecodeClip(bytes.NewReader(stream), DecodeOptions{SampleRate: 16000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Rate != 16000 || c.Channels != 1 || c.Duration() != 100*time.Millisecond {
+		t.Fatalf("clip %d Hz, %d channels, %s, want 16000 Hz mono 100ms", c.Rate, c.Channels, c.Duration())
+	}
+	if got := c.Samples(); len(got) != 5*320 || !equalSamples(firstSamples(c.PCM, 640), wantFrames(5)) {
+		t.Fatalf("%d samples, frames %v", len(got), firstSamples(c.PCM, 640))
+	}
+	format, data := parseWav(t, c.WAV())
+	if format.sampleRate != 16000 || format.channels != 1 || !bytes.Equal(data, c.PCM) {
+		t.Fatalf("WAV: %d Hz, %d channels, %d bytes", format.sampleRate, format.channels, len(data))
+	}
+	r := c.Resample(8000)
+	if r.Rate != 8000 || len(r.PCM) != len(c.PCM)/2 || c.Rate != 16000 {
+		t.Fatalf("Resample: %d Hz, %d bytes; original now %d Hz", r.Rate, len(r.PCM), c.Rate)
+	}
+}
+
+func TestDecodeClipTrim(t *testing.T) {
+	useFake(t, newFakeNative())
+	stream := withFooter(buildStream(nil, payloads(5, 4)...))
+	// 按整帧的毫秒数去掉开头一帧和结尾两帧
+	c, err := DecodeClip(bytes.NewReader(stream), DecodeOptions{SampleRate: 16000, TrimStartMs: 20, TrimEndMs: 40})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := firstSamples(c.PCM, 640), wantFrames(5)[1:3]; len(c.PCM) != 2*640 || !equalSamples(got, want) {
+		t.Fatalf("trimmed clip %d bytes, frames %v, want %v", len(c.PCM), got, want)
+	}
+	if c.Duration() != 40*time.Millisecond {
+		t.Fatalf("trimmed Duration = %s, want 40ms", c.Duration())
+	}
+	// 超过音频长度时结果为空而不是报错
+	c, err = DecodeClip(bytes.NewReader(stream), DecodeOptions{SampleRate: 16000, TrimStartMs: 60, TrimEndMs: 60})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.PCM) != 0 || c.Duration() != 0 || c.Rate != 16000 {
+		t.Fatalf("over-trimmed clip %d bytes, %s at %d Hz, want empty", len(c.PCM), c.Duration(), c.Rate)
+	}
+}
+
+func TestDecodeClipEffectiveRate(t *testing.T) {
+	useFake(t, rateFake{newFakeNative(), 24000})
+	c, err := DecodeClip(bytes.NewReader(withFooter(buildStream(nil, payloads(5, 4)...))), DecodeOptions{SampleRate: 16000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 按 dll 实际输出的采样率记录
+	if c.Rate != 24000 || c.Duration() != 100*time.Millisecond {
+		t.Fatalf("clip %d Hz, %s, want 24000 Hz, 100ms", c.Rate, c.Duration())
+	}
+}
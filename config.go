@@ -0,0 +1,57 @@
+package silk
+
+import "fmt"
+
+// Config 解码器的输出格式, 同时用于 dll 的配置和 wav 文件头, 见 Configure
+type Config struct {
+	SampleRate      int // 输出采样率, 必须是 dll 支持的采样率
+	Channels        int // 声道数, dll 只输出单声道, 为 0 时为 1
+	FramesPerPacket int // 传给 setFramesPerPacket 的每包帧数, 为 0 时为 1
+}
+
+// Configure 校验并保存 cfg, 之后该解码器的 dll 配置和 WavOptions 都由 cfg 决定
+// 解码时 DecodeOptions.SampleRate 为 0 则使用 cfg.SampleRate, 与之不同时返回 ErrConfigMismatch
+func (s *silk) Configure(cfg Config) error {
+	if cfg.Channels == 0 {
+		cfg.Channels = 1
+	}
+	if cfg.FramesPerPacket == 0 {
+		cfg.FramesPerPacket = 1
+	}
+	if cfg.Channels != 1 {
+		return fmt.Errorf("invalid config: silk dll only outputs mono, got %d channels", cfg.Channels)
+	}
+	if cfg.FramesPerPacket < 1 || cfg.FramesPerPacket > MAX_INPUT_FRAMES {
+		return fmt.Errorf("invalid config: frames per packet %d, expected 1~%d", cfg.FramesPerPacket, MAX_INPUT_FRAMES)
+	}
+	if err := s.checkSampleRate(cfg.SampleRate); err != nil {
+		return err
+	}
+	s.cfg = cfg
+	return nil
+}
+
+// WavOptions 返回与 Configure 的配置一致的 wav 参数, 未配置时为默认值
+func (s *silk) WavOptions() WavOptions {
+	return WavOptions{SampleRate: s.cfg.SampleRate, Channels: s.cfg.Channels}.withDefaults()
+}
+
+// options 按 Configure 的配置补全 opts 并应用默认值
+func (s *silk) options(opts DecodeOptions) (DecodeOptions, error) {
+	if s.cfg.SampleRate > 0 {
+		if opts.SampleRate == 0 {
+			opts.SampleRate = s.cfg.SampleRate
+		} else if opts.SampleRate != s.cfg.SampleRate {
+			return opts, fmt.Errorf("%w: decode sample rate %d, configured %d", ErrConfigMismatch, opts.SampleRate, s.cfg.SampleRate)
+		}
+	}
+	return opts.withDefaults(), nil
+}
+
+// framesPerPacket 返回传给 setFramesPerPacket 的值
+func (s *silk) framesPerPacket() int {
+	if s.cfg.FramesPerPacket > 0 {
+		return s.cfg.FramesPerPacket
+	}
+	return 1
+}
@@ -0,0 +1,91 @@
+package silk
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestConfigurePreventsRateMismatch(t *testing.T) {
+	f := newFakeNative()
+	s := fakeDecoder(f)
+	if err := s.Configure(Config{SampleRate: 24000}); err != nil {
+		t.Fatal(err)
+	}
+	stream := buildStream(nil, payloads(2, 4)...)
+
+	// 与配置不同的采样率在创建 dll 解码器之前被拒绝
+	if _, err := s.DecodeWithOptions(bytes.NewReader(stream), DecodeOptions{SampleRate: 16000}); !errors.Is(err, ErrConfigMismatch) {
+		t.Fatalf("DecodeWithOptions: err = %v, want ErrConfigMismatch", err)
+	}
+	if err := s.DecodeStream(io.Discard, bytes.NewReader(stream), DecodeOptions{SampleRate: 8000}); !errors.Is(err, ErrConfigMismatch) {
+		t.Fatalf("DecodeStream: err = %v, want ErrConfigMismatch", err)
+	}
+	if _, _, _, err := s.DecodeBoth(bytes.NewReader(stream), DecodeOptions{SampleRate: 48000}); !errors.Is(err, ErrConfigMismatch) {
+		t.Fatalf("DecodeBoth: err = %v, want ErrConfigMismatch", err)
+	}
+	if f.created != 0 {
+		t.Fatalf("mismatched calls created %d decoders", f.created)
+	}
+
+	// 未指定采样率时 dll 和 wav 文件头都使用配置的采样率
+	wav, pcm, _, err := s.DecodeBoth(bytes.NewReader(stream), DecodeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.checkLeaks(t)
+	if len(f.rates) != 1 {
+		t.Fatalf("set sample rate on %d handles, want 1", len(f.rates))
+	}
+	for handle, rate := range f.rates {
+		if rate != 24000 {
+			t.Errorf("handle %d: dll sample rate %d, want 24000", handle, rate)
+		}
+	}
+	format, data := parseWav(t, wav)
+	if format.sampleRate != 24000 || format.byteRate != 48000 || len(pcm) != 2*960 || !bytes.Equal(data, pcm) {
+		t.Fatalf("wav rate %d, byte rate %d, %d pcm bytes", format.sampleRate, format.byteRate, len(pcm))
+	}
+	if got := s.WavOptions(); got.SampleRate != 24000 || got.Channels != 1 {
+		t.Fatalf("WavOptions = %+v, want 24000 Hz mono", got)
+	}
+}
+
+func TestConfigureFramesPerPacket(t *testing.T) {
+	f := newFakeNative()
+	s := fakeDecoder(f)
+	if err := s.Configure(Config{SampleRate: 16000, FramesPerPacket: 3}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Decode(bytes.NewReader(buildStream(nil, payloads(1, 4)...))); err != nil {
+		t.Fatal(err)
+	}
+	f.checkLeaks(t)
+	if len(f.packets) != 1 {
+		t.Fatalf("set frames per packet on %d handles, want 1", len(f.packets))
+	}
+	for handle, n := range f.packets {
+		if n != 3 {
+			t.Errorf("handle %d: frames per packet %d, want 3", handle, n)
+		}
+	}
+}
+
+func TestConfigureRejectsInvalid(t *testing.T) {
+	for _, cfg := range []Config{
+		{SampleRate: 0},
+		{SampleRate: 16000, Channels: 2},
+		{SampleRate: 16000, FramesPerPacket: MAX_INPUT_FRAMES + 1},
+		{SampleRate: 16000, FramesPerPacket: -1},
+	} {
+		s := fakeDecoder(newFakeNative())
+		if err := s.Configure(cfg); err == nil {
+			t.Errorf("Configure(%+v) succeeded", cfg)
+		}
+		// 校验失败时不保留配置
+		if got := s.WavOptions(); got.SampleRate != 16000 || got.Channels != 1 {
+			t.Errorf("after rejected %+v: WavOptions = %+v", cfg, got)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package silk
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"time"
+)
+
+// DecodeConn 从 conn 读取并解码 silk 流, 返回 pcm 数据
+// 每次读取 conn 前把读超时设为 idleTimeout 之后, 对端停止发送超过 idleTimeout 时返回错误,
+// 该错误满足 errors.Is(err, os.ErrDeadlineExceeded); idleTimeout <= 0 表示不限制
+func DecodeConn(conn net.Conn, idleTimeout time.Duration, opts DecodeOptions) ([]byte, error) {
+	src := &idleReader{conn: conn, timeout: idleTimeout}
+	if idleTimeout > 0 {
+		defer conn.SetReadDeadline(time.Time{})
+	}
+	return newDecoder().DecodeWithOptions(src, opts)
+}
+
+// idleReader 每次 Read 前刷新 conn 的读超时
+type idleReader struct {
+	conn    net.Conn
+	timeout time.Duration
+}
+
+func (r *idleReader) Read(p []byte) (int, error) {
+	if r.timeout <= 0 {
+		return r.conn.Read(p)
+	}
+	if err := r.conn.SetReadDeadline(time.Now().Add(r.timeout)); err != nil {
+		return 0, err
+	}
+	n, err := r.conn.Read(p)
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		err = fmt.Errorf("connection idle for %s: %w", r.timeout, err)
+	}
+	return n, err
+}
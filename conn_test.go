@@ -0,0 +1,58 @@
+package silk
+
+import (
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestDecodeConn(t *testing.T) {
+	f := newFakeNative()
+	useFake(t, f)
+	client, server := net.Pipe()
+	defer server.Close()
+	stream := withFooter(buildStream(nil, payloads(4, 4)...))
+	go func() {
+		// 分几次发送, 每次的间隔都小于 idleTimeout
+		for i := 0; i < len(stream); i += 10 {
+			end := i + 10
+			if end > len(stream) {
+				end = len(stream)
+			}
+			client.Write(stream[i:end])
+			time.Sleep(5 * time.Millisecond)
+		}
+		client.Close()
+	}()
+	pcm, err := DecodeConn(server, time.Second, DecodeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.checkLeaks(t)
+	if !equalSamples(firstSamples(pcm, 640), wantFrames(4)) {
+		t.Fatalf("frames %v, want %v", firstSamples(pcm, 640), wantFrames(4))
+	}
+}
+
+func TestDecodeConnIdleTimeout(t *testing.T) {
+	f := newFakeNative()
+	useFake(t, f)
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	go func() {
+		// 发送一部分后停止发送, 但不关闭连接
+		client.Write(buildStream(nil, payloads(2, 4)...))
+	}()
+	start := time.Now()
+	_, err := DecodeConn(server, 50*time.Millisecond, DecodeOptions{})
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Fatalf("err = %v, want os.ErrDeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("returned after %s", elapsed)
+	}
+	f.checkLeaks(t)
+}
@@ -0,0 +1,85 @@
+package silk
+
+import (
+	"fmt"
+	"io"
+)
+
+// CountingReader 统计已从 R 读出的字节数
+type CountingReader struct {
+	R io.Reader
+	N int64 // 已读出的字节数
+}
+
+func (c *CountingReader) Read(p []byte) (int, error) {
+	n, err := c.R.Read(p)
+	c.N += int64(n)
+	return n, err
+}
+
+// countingWriter 统计写入 w 的字节数
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// progressReporter 按解码循环已消费的字节数与 total 的比例回调, 只在百分比增加时回调
+type progressReporter struct {
+	total int64
+	cb    func(percent float64)
+	last  float64
+}
+
+func (p *progressReporter) report(consumed int64) {
+	percent := float64(consumed) * 100 / float64(p.total)
+	if percent > 100 {
+		percent = 100
+	}
+	if percent > p.last {
+		p.last = percent
+		p.cb(percent)
+	}
+}
+
+// DecodeWithPercent 按 opts 解码, 解码过程中以已消费的字节数占 totalBytes 的百分比(0~100)回调 cb, 用于进度条
+// 按解码循环实际处理到的位置计算, 不包括读取缓冲预读的部分, 每个 block 之后最多回调一次.
+// totalBytes <= 0 且 src 实现 io.Seeker 时从当前位置到结尾计算; 成功结束时总会回调 100
+// (输入比 totalBytes 短时会从当前进度直接跳到 100)
+func DecodeWithPercent(src io.Reader, totalBytes int64, cb func(percent float64), opts DecodeOptions) ([]byte, error) {
+	if totalBytes <= 0 {
+		if seeker, ok := src.(io.Seeker); ok {
+			cur, err := seeker.Seek(0, io.SeekCurrent)
+			if err != nil {
+				return nil, err
+			}
+			end, err := seeker.Seek(0, io.SeekEnd)
+			if err != nil {
+				return nil, err
+			}
+			if _, err = seeker.Seek(cur, io.SeekStart); err != nil {
+				return nil, err
+			}
+			totalBytes = end - cur
+		}
+	}
+	if totalBytes <= 0 {
+		return nil, fmt.Errorf("unknown input size for progress")
+	}
+	cb(0)
+	p := &progressReporter{total: totalBytes, cb: cb}
+	opts.progress = p.report
+	pcm, err := newDecoder().DecodeWithOptions(src, opts)
+	if err != nil {
+		return nil, err
+	}
+	if p.last < 100 {
+		cb(100)
+	}
+	return pcm, nil
+}
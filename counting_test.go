@@ -0,0 +1,82 @@
+package silk
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestCountingReader(t *testing.T) {
+	c := &CountingReader{R: iotest.OneByteReader(strings.NewReader("hello, silk"))}
+	buf := make([]byte, 4)
+	n, err := io.ReadFull(c, buf)
+	if err != nil || n != 4 || c.N != 4 {
+		t.Fatalf("ReadFull = %d, %v; N = %d, want 4", n, err, c.N)
+	}
+	rest, err := io.ReadAll(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(rest) != "o, silk" || c.N != int64(len("hello, silk")) {
+		t.Fatalf("rest %q, N = %d", rest, c.N)
+	}
+}
+
+func TestCountingReaderError(t *testing.T) {
+	boom := errors.New("boom")
+	c := &CountingReader{R: io.MultiReader(strings.NewReader("abc"), iotest.ErrReader(boom))}
+	data, err := io.ReadAll(c)
+	if !errors.Is(err, boom) || string(data) != "abc" || c.N != 3 {
+		t.Fatalf("ReadAll = %q, %v; N = %d", data, err, c.N)
+	}
+}
+
+func TestDecodeWithPercent(t *testing.T) {
+	useFake(t, newFakeNative())
+	stream := withFooter(buildStream(nil, payloads(200, 40)...))
+	var got []float64
+	pcm, err := DecodeWithPercent(bytes.NewReader(stream), 0, func(p float64) { got = append(got, p) }, DecodeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pcm) != 200*640 {
+		t.Fatalf("decoded %d bytes, want %d", len(pcm), 200*640)
+	}
+	if len(got) < 3 || got[0] != 0 || got[len(got)-1] != 100 {
+		t.Fatalf("progress %v, want 0 ... 100", got)
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i] <= got[i-1] {
+			t.Fatalf("progress not increasing: %v", got)
+		}
+	}
+}
+
+func TestDecodeWithPercentTracksConsumedBytes(t *testing.T) {
+	useFake(t, newFakeNative())
+	// 远小于读取缓冲, 按预读计算时第一次回调就会是 100
+	stream := withFooter(buildStream(nil, payloads(50, 30)...))
+	total := float64(len(stream))
+	var got []float64
+	if _, err := DecodeWithPercent(bytes.NewReader(stream), 0, func(p float64) { got = append(got, p) }, DecodeOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	// 0, 每个 block 之前(文件头之后每次 32 字节), footer 之后的 100
+	want := []float64{0}
+	for k := 0; k <= 50; k++ {
+		want = append(want, float64(HeaderLen+32*k)*100/total)
+	}
+	want = append(want, 100)
+	if len(got) != len(want) {
+		t.Fatalf("%d callbacks %v, want %d", len(got), got, len(want))
+	}
+	for i := range want {
+		if math.Abs(got[i]-want[i]) > 1e-9 {
+			t.Fatalf("callback %d = %.3f%%, want %.3f%% of consumed bytes", i, got[i], want[i])
+		}
+	}
+}
@@ -4,15 +4,17 @@ import "C"
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"hash/crc32"
 	"io"
-	"syscall"
+	"math"
+	"strings"
+	"sync"
+	"time"
 	"unsafe"
-
-	"github.com/0xrawsec/golang-utils/log"
-	"golang.org/x/sys/windows"
 )
 
 const (
@@ -25,12 +27,32 @@ const (
 	MAX_API_FS_KHZ           = 48
 	// 默认值
 	defaultSampleRate = 24000
+	// 单个 block 长度的合理上限
+	maxBlockBytes = MAX_BYTES_PER_FRAME * MAX_INPUT_FRAMES
+	// 自动判断字节序时检查的帧数
+	detectFrames = 4
+	// 文件头与第一帧之间最多允许的零长度前缀(填充的 0x0000)个数
+	maxLeadingZeroBlocks = 32
+	// 连续这么多帧(1s)没有输出即认为 dll 工作异常
+	maxZeroOutputFrames = 50
+	// 默认的读缓冲大小, 文件/网络输入时比 bufio 默认的 4096 减少读调用次数
+	defaultReadBufferSize = 64 << 10
+	// 自动判断字节序时最多检查的字节数
+	detectWindow = 4096
+	// TolerateTextCorruption 时文件头之前最多跳过的字节数
+	maxTextPrefix = 16
+	// ExpectedDuration 的默认容差, 两帧
+	defaultDurationTolerance = 2 * FRAME_LENGTH_MS * time.Millisecond
 )
 
-func checkHeader(reader *bufio.Reader) error {
+// checkHeader 检查文件头, 开头的 STX 或 leading(为 0 时只识别 STX)之后紧跟文件头时丢弃该字节
+func checkHeader(reader *bufio.Reader, leading byte, logger Logger) error {
+	if err := skipAMRWBMagic(reader, logger); err != nil {
+		return err
+	}
 	first, err := reader.Peek(1)
 	if err != nil {
-		log.Warn("io error / failed to peek first byte: %+v", err)
+		logger.Warn("io error / failed to peek first byte: %+v", err)
 		return fmt.Errorf("failed to peek first byte: %w", err)
 	}
 	// 如果第一位是 0x02 需要丢弃
@@ -40,179 +62,975 @@ func checkHeader(reader *bufio.Reader) error {
 	// https://github.com/kn007/silk-v3-decoder/blob/master/silk/test/Decoder.c#L187
 	// 原始开源版本:(不识别 0x02 开头的文件)
 	// https://github.com/gaozehua/SILKCodec/blob/master/SILK_SDK_SRC_ARM/test/Decoder.c#L182
-	if first[0] == STX {
-		log.Info("first byte is STX(%x), read it", STX)
-		stx, err := reader.ReadByte()
-		if err != nil {
-			log.Warn("read first byte error: %+v", err)
+	// 只有后面 9 字节正好是文件头时才丢弃, 否则按文件头在开头处理, 避免误删真实数据
+	head, _ := reader.Peek(HeaderLen + 1)
+	isLeading := first[0] == STX || (leading != 0 && first[0] == leading)
+	if isLeading && len(head) == HeaderLen+1 && string(head[1:]) == Header {
+		logger.Info("first byte is leading byte(%x), read it", first[0])
+		if _, err := reader.ReadByte(); err != nil {
+			logger.Warn("read first byte error: %+v", err)
 			return fmt.Errorf("failed to read first byte: %w", err)
 		}
-		if stx != STX {
-			log.Warn("read first byte not STX: %x", stx)
-			return fmt.Errorf("invalid first byte: %d, expected=%d", stx, STX)
-		}
+	}
+	// 误把 wav 当作 silk 传入时给出明确的错误, 调用方可以跳过转换
+	if magic, _ := reader.Peek(12); isWav(magic) {
+		logger.Warn("input is already wav")
+		return ErrAlreadyWav
 	}
 	// 文件头
 	var header = make([]byte, HeaderLen)
 	n, err := io.ReadFull(reader, header)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		logger.Warn("file header truncated, read %d bytes, expected %d", n, HeaderLen)
+		return fmt.Errorf("%w: read %d bytes, expected %d", ErrTruncatedHeader, n, HeaderLen)
+	}
 	if err != nil {
-		log.Warn("failed to read file header: %+v", err)
+		logger.Warn("failed to read file header: %+v", err)
 		return fmt.Errorf("failed to read file header: %w", err)
 	}
-	if n != HeaderLen {
-		log.Warn("invalid file header, read %d bytes, expected %d", n, HeaderLen)
-		return fmt.Errorf("invalid file header, length=%d, expected=%d", n, HeaderLen)
-	}
 	if string(header) != Header {
-		log.Warn("invalid file header %q expected %q", header, HeaderLen)
-		return fmt.Errorf("invalid file header, got=%q, expected=%q", header, Header)
+		logger.Warn("invalid file header %q expected %q", header, Header)
+		return fmt.Errorf("%w, got=%q, expected=%q", ErrInvalidHeader, header, Header)
+	}
+	return nil
+}
+
+// utf8BOM UTF-8 字节序标记
+var utf8BOM = []byte{0xEF, 0xBB, 0xBF}
+
+// skipTextPrefix 丢弃文件头之前的 UTF-8 BOM 和空白/换行, 最多 maxTextPrefix 字节
+func skipTextPrefix(reader *bufio.Reader, logger Logger) error {
+	prefix, _ := reader.Peek(maxTextPrefix)
+	n := 0
+	if bytes.HasPrefix(prefix, utf8BOM) {
+		n = len(utf8BOM)
+	}
+	for n < len(prefix) && strings.IndexByte(" \t\r\n", prefix[n]) >= 0 {
+		n++
+	}
+	if n > 0 {
+		logger.Info("skip %d bytes of text prefix before header", n)
+		if _, err := reader.Discard(n); err != nil {
+			return fmt.Errorf("failed to skip text prefix: %w", err)
+		}
+	}
+	return nil
+}
+
+// checkTrailer 按 mode 处理 footer 之后的数据
+func checkTrailer(reader *bufio.Reader, mode TrailerMode) error {
+	switch mode {
+	case TrailerDiscard:
+		if _, err := io.Copy(io.Discard, reader); err != nil {
+			return fmt.Errorf("failed to discard trailing data: %w", err)
+		}
+	case TrailerStrict:
+		rest, _ := reader.Peek(HeaderLen + 1)
+		if len(rest) > 0 && !hasStreamHeader(rest) {
+			return fmt.Errorf("%w: % x", ErrTrailingData, rest)
+		}
 	}
 	return nil
 }
 
+// amrWBMagic AMR-WB 文件(RFC 4867)的开头
+const amrWBMagic = "#!AMR-WB\n"
+
+// maxHeaderPrefix checkHeader 判断文件头最多需要查看的字节数: AMR-WB 魔数、0x02 和文件头
+const maxHeaderPrefix = len(amrWBMagic) + 1 + HeaderLen
+
+// skipAMRWBMagic 处理以 AMR-WB 魔数开头的输入
+// 部分安卓导出工具在 silk 文件前加上 AMR-WB 魔数, 其后紧跟 silk 文件头(可能带 0x02)时丢弃魔数继续解码;
+// 否则是真正的 AMR-WB, 返回 ErrUnsupportedAMRWB 而不是难以理解的文件头错误
+func skipAMRWBMagic(reader *bufio.Reader, logger Logger) error {
+	head, _ := reader.Peek(maxHeaderPrefix)
+	if !bytes.HasPrefix(head, []byte(amrWBMagic)) {
+		return nil
+	}
+	payload := head[len(amrWBMagic):]
+	if len(payload) > 0 && payload[0] == STX {
+		payload = payload[1:]
+	}
+	if !bytes.HasPrefix(payload, []byte(Header)) {
+		logger.Warn("input is AMR-WB, not silk")
+		return ErrUnsupportedAMRWB
+	}
+	logger.Info("silk payload wrapped with AMR-WB magic, skip it")
+	_, err := reader.Discard(len(amrWBMagic))
+	return err
+}
+
+// isWav 判断 b 是否以 RIFF....WAVE 开始
+func isWav(b []byte) bool {
+	return len(b) >= 12 && string(b[0:4]) == "RIFF" && string(b[8:12]) == "WAVE"
+}
+
+// detectByteOrder 根据前几帧的长度前缀推断字节序
+// 分别按小端/大端跳读已缓冲的数据, 取得到更多合理长度的一方, 相同时取小端
+func detectByteOrder(reader *bufio.Reader, logger Logger) binary.ByteOrder {
+	window := detectWindow
+	if reader.Size() < window {
+		window = reader.Size()
+	}
+	data, _ := reader.Peek(window)
+	little := countPlausibleBlocks(data, binary.LittleEndian)
+	big := countPlausibleBlocks(data, binary.BigEndian)
+	if big > little {
+		logger.Info("block size prefix looks big-endian (%d vs %d plausible blocks)", big, little)
+		return binary.BigEndian
+	}
+	return binary.LittleEndian
+}
+
+// countPlausibleBlocks 按 order 解析 data 中的 block, 返回长度在 1~maxBlockBytes 内的连续 block 数
+func countPlausibleBlocks(data []byte, order binary.ByteOrder) int {
+	var count int
+	for count < detectFrames && len(data) >= 2 {
+		nByte := int(int16(order.Uint16(data)))
+		if nByte <= 0 || nByte > maxBlockBytes {
+			break
+		}
+		count++
+		data = data[2:]
+		if nByte > len(data) {
+			break // 超出已缓冲的数据, 无法继续检查
+		}
+		data = data[nByte:]
+	}
+	return count
+}
+
+// NewSilkDecoder 加载 dllsilk.dll 创建解码器
+// 优先使用系统搜索路径(程序目录等)中部署的 dllsilk.dll, 找不到时使用包内置的副本
 func NewSilkDecoder() *silk {
 	s := new(silk)
-	s.init()
+	s.err = s.init()
+	return s
+}
+
+// newDecoder 包内的函数(DecodePlaylist、ServeWav 等)创建解码器时使用的构造函数, 测试时替换为不加载 dll 的实现
+var newDecoder = NewSilkDecoder
+
+// NewSilkDecoderWithDLL 从指定路径加载 dll 创建解码器
+func NewSilkDecoderWithDLL(path string) *silk {
+	s := &silk{path: path}
+	s.err = s.init()
 	return s
 }
 
 type silk struct {
-	dll *syscall.DLL
+	path    string // dll 路径, 为空时见 NewSilkDecoder
+	dll     *dllModule
+	machine uint16 // dll PE 头中的 Machine, 见 DLLArch
+	err     error  // 加载 dll 时的错误, 在调用 proc 时返回
+
+	mu       sync.Mutex
+	procs    map[string]*dllProc // 逻辑 proc 名 -> 实际解析到的 proc
+	procErrs map[string]error    // 逻辑 proc 名 -> 所有候选名都找不到时的错误
+
+	ratesOnce   sync.Once
+	rates       []int // 支持的输出采样率, 见 SupportedRates
+	ratesProbed bool  // rates 由 dll 的 getSupportedRates 返回
+	ratesErr    error
+
+	native native // 为 nil 时直接调用 dll, 见 lib
+	cfg    Config // 见 Configure
 }
 
 func (s *silk) init() error {
-	silkDll, err := syscall.LoadDLL(`dllsilk.dll`)
+	path := s.path
+	if path == "" {
+		path = `dllsilk.dll`
+	}
+	silkDll, err := s.loadDLL(path)
+	if err != nil && s.path == "" && errors.Is(err, errDLLNotFound) {
+		// 没有部署 dllsilk.dll, 使用内置的副本
+		embedded, extractErr := extractEmbeddedDLL()
+		if extractErr != nil {
+			return extractErr
+		}
+		silkDll, err = s.loadDLL(embedded)
+	}
 	if err != nil {
+		if errors.Is(err, errBadExeFormat) && dllArchHint != "" {
+			return fmt.Errorf("%s: %w", dllArchHint, err)
+		}
 		return err
 	}
 	s.dll = silkDll
 	return nil
 }
 
-func (s silk) Decode(src io.Reader) ([]byte, error) {
-	var reader = bufio.NewReader(src)
-	/* Check Silk header */
-	if err := checkHeader(reader); err != nil {
-		return nil, err
+// DecodeOptions 解码选项, 零值即为默认行为
+type DecodeOptions struct {
+	// SampleRate 输出采样率, 为 0 时使用 16000
+	SampleRate int
+	// LengthByteOrder block 长度前缀的字节序, 标准格式为小端
+	// 为 nil 时根据前几帧的长度是否合理自动判断, 无法判断时使用小端
+	LengthByteOrder binary.ByteOrder
+	// RetryFirstFrame 首帧解码失败时重建一次解码器后重试
+	// 部分 dll 在 CreateDecoder 之后的第一次 Decode 会偶发失败
+	RetryFirstFrame bool
+	// Speed 播放速度, 如 1.5/2 倍速, 变速不变调; 0 或 1 表示不处理
+	// 需要完整的 pcm, 只在 DecodeWithOptions 等整段解码时生效, DecodeStream 等流式解码时返回 ErrWholeStreamOption
+	Speed float64
+	// Gain 音量增益(dB), 如 6 约为两倍幅度; 超出范围的采样饱和到 ±32767
+	Gain float64
+	// HighPassFilter 一阶高通滤波的截止频率(Hz), 去除直流偏移和低频噪声, 在 Gain 之前处理; 0 表示不处理
+	HighPassFilter float64
+	// ResampleTo 解码后再重采样到该采样率, 用于 dll 不支持的采样率; 0 表示不处理
+	// 与 Speed 一样只在整段解码时生效
+	ResampleTo int
+	// Resampler 自定义重采样实现, 为 nil 时使用 ResampleQuality 对应的内置实现
+	Resampler Resampler
+	// ResampleQuality 内置重采样的质量, 默认 ResampleFast; 如 24000 -> 8000 降采样时建议使用 ResampleHigh
+	ResampleQuality ResampleQuality
+	// GapBetween DecodePlaylist 中相邻两段之间插入的静音时长
+	GapBetween time.Duration
+	// Crossfade DecodePlaylist 中相邻两段重叠交叉淡化的时长, 避免拼接处的爆音; 与 GapBetween 互斥
+	Crossfade time.Duration
+	// MaxFrames 最多解码的 block 数, 超出时返回 ErrMaxFramesExceeded; 0 表示不限制
+	MaxFrames int
+	// AbandonOnCancel 在单独的 goroutine 中调用 dll 的 Decode, ctx 取消时不再等待它返回.
+	// dll 卡在某一帧时也能及时返回, 代价是泄漏这个解码器 handle(调用仍在使用它, 不能关闭)
+	AbandonOnCancel bool
+	// Deadline 解码必须在此时间前完成, 每帧解码前检查, 超时返回 ErrDeadlineExceeded
+	// 与 DecodeStreamContext 的 ctx 同时设置截止时间时以较早的为准: Deadline 先到返回 ErrDeadlineExceeded,
+	// ctx 先到返回 ctx.Err()
+	Deadline time.Time
+	// Profile 统计每次 dll Decode 调用的耗时, 结果见 DecodeInfo.Profile; 关闭时没有额外开销
+	Profile bool
+	// FrameDumpDir 调试用, 将每帧 pcm 写为该目录下的 frame_000123.wav(编号为 block 序号), 最多 1000 帧
+	FrameDumpDir string
+	// AllowEmpty 没有解码出任何 pcm 时返回空结果而不是 ErrNoAudio
+	AllowEmpty bool
+	// ReadBufferSize 读取 src 的缓冲大小, 为 0 时使用 64 KiB
+	ReadBufferSize int
+	// EmitDtxSilence 遇到长度为 0 的 block(DTX, 不连续传输的静音段)时输出一帧(20ms)静音,
+	// 保持与原始时间轴对齐; 第一帧之前的零长度前缀视为填充, 不输出
+	EmitDtxSilence bool
+	// PadToSeconds 在末尾补静音, 使输出时长为整秒(按最终输出的采样率), wav 文件头的长度包含补齐部分
+	PadToSeconds bool
+	// DownmixToMono 个别移植版本的 dll 把 framesPerPacket 当作声道数, 输出交织的多声道 pcm;
+	// 开启时检测声道数并把各声道平均为单声道, 声道数无法识别或中途变化时返回 ErrUnexpectedChannels.
+	// 声道数按每次 Decode 的输出长度判断: 单声道每次最多输出 framesPerPacket(默认 1, 见 Configure)帧,
+	// 输出为这个长度的整数 k 倍时视为 k 声道. 一个 packet 含多帧(40/60ms)的单声道文件会被误判,
+	// 因此只应对已知输出多声道的 dll 开启; 默认不检测, 输出总是按单声道处理
+	DownmixToMono bool
+	// Trailer footer 之后剩余数据的处理方式, 默认 TrailerLeave
+	Trailer TrailerMode
+	// SpillToDisk DecodeReader 将 pcm 写入临时文件而不是内存, 用于解码很长的音频
+	SpillToDisk bool
+	// SpillDir SpillToDisk 临时文件所在的目录, 为空时使用 os.TempDir()
+	SpillDir string
+	// TraceProcs 通过 logger 的 Debug 级别记录每次 dll 调用的参数和返回值, 只记录数据长度不记录音频内容
+	TraceProcs bool
+	// LeadingByte 文件头之前需要丢弃的前缀字节, 用于不使用 0x02 的非标准导出工具;
+	// 标准的 STX(0x02) 始终会被识别, 为 0 时只识别 STX
+	LeadingByte byte
+	// HashInput 在解码的同时计算整个输入的 CRC32(IEEE), 结果见 DecodeInfo.InputHash, 用于去重
+	HashInput bool
+	// Alloc 分配输出 pcm 和解码用的缓冲区, 为 nil 时使用 make; 可以对接内存池以减少 GC 压力.
+	// 返回的切片长度至少为 n; 解码用的缓冲区在解码函数返回后不再使用, 输出 pcm 的所有权交给调用方.
+	// 开启 AbandonOnCancel 时解码用的缓冲区改用 make 分配, 被放弃的 dll 调用可能在返回后继续写入它们.
+	// Speed/ResampleTo 等整段处理产生的数据仍由 make 分配
+	Alloc func(n int) []byte
+	// MaxOutputBytes 输出的 pcm 达到该字节数后(写完当前帧)停止解码并正常返回,
+	// DecodeInfo.Truncated 为 true, 用于只需要开头部分的预览; 0 表示不限制
+	MaxOutputBytes int
+	// StrictMode 拒绝所有非标准的输入, 每种情况返回不同的错误:
+	// 文件头之前的 STX/LeadingByte 前缀(ErrLeadingByte)、自动识别出大端序长度(ErrBigEndianLengths)、
+	// 长度为 0 的 block(ErrZeroLengthBlock)、没有 footer(ErrMissingFooter)、
+	// footer 之后的其他数据(ErrTrailingData, Trailer 为默认值时按 TrailerStrict 处理)、
+	// dll 改用了其他采样率(ErrSampleRateOverridden)、文件头之后的 TDUR/TLEN 扩展块(ErrExtendedHeader)
+	StrictMode bool
+	// MaxPCMBytes 解码输出的 pcm 最多字节数, 每次写出前检查, 超出时返回 ErrOutputTooLarge;
+	// 防止构造的小文件解码出巨大的 pcm 耗尽内存, 与 MaxFrames 配合使用; 0 表示不限制
+	MaxPCMBytes int
+	// TolerateTextCorruption 跳过文件头之前的 UTF-8 BOM 和空白/换行(最多 16 字节),
+	// 挽救经过文本模式 FTP/复制的文件; 数据部分被改写(如 LF 变为 CRLF)的文件无法恢复
+	TolerateTextCorruption bool
+	// AGC 自动增益控制, 把不同录音的电平归一到 AGCOptions.TargetRMS 附近, 在 HighPassFilter 之后、Gain 之前处理
+	AGC bool
+	// FrameProcessor 逐帧处理 pcm 的回调, 在内置的滤波和增益之后调用, 返回值(长度可以不同)代替该帧写出;
+	// frameIdx 为从 0 开始的解码帧序号. pcm 每帧重新分配, 可以原地修改并直接返回, 回调返回后包内不再使用它
+	FrameProcessor func(frameIdx int, pcm []int16) []int16
+	// TrimStartMs/TrimEndMs 解码后去掉开头/结尾这么多毫秒的 pcm(如按键声), 在其他整段处理之前进行;
+	// 两者之和超过音频长度时结果为空, DecodeInfo.TrimmedAll 为 true. 只在整段解码时生效
+	TrimStartMs, TrimEndMs int
+	// Events 接收解码过程中的 DecodeEvent(见 EventKind), 用于追踪和监控; 发送不阻塞, channel 满时丢弃事件
+	Events chan<- DecodeEvent
+	// Logger 只用于本次调用的日志, 包括文件头检查; 为 nil 时使用 SetLogger 设置的日志
+	Logger Logger
+	// OddLength dll 返回的输出长度不是整数个 16bit 采样时的处理方式, 默认 OddLengthRoundDown
+	OddLength OddLengthPolicy
+	// Companding 把输出转换为 G.711 μ-law/A-law(每个采样 1 字节), 配合 ResampleTo: 8000 得到电话系统使用的格式;
+	// 在所有处理之后转换, 与 Speed 一样只在整段解码时生效
+	Companding Companding
+	// AGCOptions AGC 的目标电平和时间常数, 零值使用默认参数
+	AGCOptions AGCOptions
+	// ExpectedDuration 预期的时长, 解码完成后与实际输出(补齐之前)比较, 相差超过 DurationTolerance 时
+	// 返回 ErrDurationMismatch, 用于发现采样率不匹配或数据被截断; 0 表示不检查
+	ExpectedDuration time.Duration
+	// DurationTolerance ExpectedDuration 允许的误差, 为 0 时为 40ms(两帧)
+	DurationTolerance time.Duration
+
+	// rateDetected 在得到 dll 实际输出的采样率之后、写出第一帧之前调用, 用于边解码边写文件头
+	rateDetected func(rate int)
+	// progress 每处理完一个 block 后以已消费的输入字节数调用, 见 DecodeWithPercent
+	progress func(consumed int64)
+	// requireFooter 没有 footer 时返回 ErrMissingFooter, 与 StrictMode 的这一项检查相同, 但不启用其他检查
+	requireFooter bool
+	// leadingDtx 开头的零长度 block 同样按 DTX 帧处理而不是填充, 用于前缀由包内生成、不会有填充的输入
+	leadingDtx bool
+}
+
+// TrailerMode footer 之后剩余数据的处理方式
+type TrailerMode int
+
+const (
+	// TrailerLeave 读到 footer 即结束, 不再读取 src
+	TrailerLeave TrailerMode = iota
+	// TrailerDiscard 读取并丢弃 footer 之后的数据直到 EOF, 用于带填充或元数据的文件
+	TrailerDiscard
+	// TrailerStrict footer 之后只允许 EOF 或下一个流的文件头, 否则返回 ErrTrailingData
+	TrailerStrict
+)
+
+// OddLengthPolicy dll 返回奇数字节长度的输出时的处理方式
+type OddLengthPolicy int
+
+const (
+	// OddLengthRoundDown 丢弃最后一个不完整的字节并记录警告, 保证输出按 16bit 对齐
+	OddLengthRoundDown OddLengthPolicy = iota
+	// OddLengthError 返回 ErrOddFrameLength
+	OddLengthError
+)
+
+// DefaultDecodeOptions 返回 Decode 使用的默认选项, 可在此基础上修改
+// 输出 16000Hz 单声道, 长度前缀字节序自动识别, 开头的 STX 自动丢弃
+func DefaultDecodeOptions() DecodeOptions {
+	return DecodeOptions{}.withDefaults()
+}
+
+func (o DecodeOptions) withDefaults() DecodeOptions {
+	if o.SampleRate <= 0 {
+		o.SampleRate = 16000
 	}
-	var blockIndex int
-	out := &bytes.Buffer{}
-	handle, err := s.createDecoder()
+	if o.ReadBufferSize <= 0 {
+		o.ReadBufferSize = defaultReadBufferSize
+	}
+	return o
+}
+
+func (s *silk) Decode(src io.Reader) ([]byte, error) {
+	return s.DecodeWithOptions(src, DecodeOptions{})
+}
+
+// DecodeWithOptions 按 opts 解码, 返回 pcm 数据
+func (s *silk) DecodeWithOptions(src io.Reader, opts DecodeOptions) ([]byte, error) {
+	pcm, _, err := s.DecodeWithInfo(src, opts)
+	return pcm, err
+}
+
+// DecodeWithInfo 同 DecodeWithOptions, 同时返回解码统计信息
+func (s *silk) DecodeWithInfo(src io.Reader, opts DecodeOptions) ([]byte, DecodeInfo, error) {
+	return s.decodeReserved(src, opts, 0)
+}
+
+// decodeReserved 同 DecodeWithInfo, 返回的切片前 reserve 字节留给调用方(如 wav 文件头), 之后为 pcm
+// 整段处理没有重新分配时直接使用解码的缓冲区, 避免再复制一次 pcm
+func (s *silk) decodeReserved(src io.Reader, opts DecodeOptions, reserve int) ([]byte, DecodeInfo, error) {
+	opts, err := s.options(opts)
 	if err != nil {
-		return nil, err
+		return nil, DecodeInfo{}, err
+	}
+	out := &allocBuffer{opts: opts}
+	if reserve > 0 {
+		out.buf = opts.alloc(reserve)
 	}
-	err = s.setSampleRate(handle, 16000)
+	// 变速和重采样会改变长度, 补齐放到 postProcess 最后
+	core := opts
+	core.PadToSeconds = false
+	core.Speed, core.ResampleTo, core.Companding = 0, 0, CompandingNone
+	core.TrimStartMs, core.TrimEndMs = 0, 0
+	info, err := s.DecodeStreamInfo(context.Background(), out, src, core)
 	if err != nil {
-		return nil, err
+		return nil, info, err
+	}
+	if info.EffectiveSampleRate > 0 {
+		opts.SampleRate = info.EffectiveSampleRate
 	}
-	err = s.setFramesPerPacket(handle, 1)
+	pcm := out.buf[reserve:]
+	if opts.TrimStartMs > 0 || opts.TrimEndMs > 0 {
+		pcm, info.TrimmedAll = trimPCM(pcm, opts.TrimStartMs, opts.TrimEndMs, opts.SampleRate)
+	}
+	pcm, err = opts.postProcess(pcm)
 	if err != nil {
-		return nil, err
+		return nil, info, err
+	}
+	if reserve == 0 {
+		return pcm, info, nil
+	}
+	if len(pcm) > 0 && len(out.buf) > reserve && &pcm[0] == &out.buf[reserve] {
+		return out.buf[:reserve+len(pcm)], info, nil
+	}
+	data := make([]byte, reserve+len(pcm))
+	copy(data[reserve:], pcm)
+	return data, info, nil
+}
+
+// requireLinear 按 16bit pcm 解释输出的函数调用, 设置了 Companding 时返回 ErrCompandedOutput
+func (o DecodeOptions) requireLinear() error {
+	if o.Companding != CompandingNone {
+		return fmt.Errorf("%w: companding %d", ErrCompandedOutput, o.Companding)
+	}
+	return nil
+}
+
+// checkStreamable 确认 o 没有设置只在整段解码时生效的选项, 流式解码时不能静默忽略它们
+func (o DecodeOptions) checkStreamable() error {
+	var names []string
+	if o.Speed > 0 && o.Speed != 1 {
+		names = append(names, "Speed")
+	}
+	if o.ResampleTo > 0 && o.ResampleTo != o.SampleRate {
+		names = append(names, "ResampleTo")
+	}
+	if o.TrimStartMs > 0 || o.TrimEndMs > 0 {
+		names = append(names, "TrimStartMs/TrimEndMs")
+	}
+	if o.Companding != CompandingNone {
+		names = append(names, "Companding")
+	}
+	if len(names) > 0 {
+		return fmt.Errorf("%w: %s", ErrWholeStreamOption, strings.Join(names, ", "))
+	}
+	return nil
+}
+
+// postProcess 对完整的 pcm 应用需要整段数据的选项
+func (o DecodeOptions) postProcess(pcm []byte) ([]byte, error) {
+	if o.Speed > 0 && o.Speed != 1 {
+		pcm = samplesToBytes(timeStretch(bytesToSamples(pcm), o.Speed, o.SampleRate))
+	}
+	if o.ResampleTo > 0 && o.ResampleTo != o.SampleRate {
+		samples, err := o.resampler().Resample(bytesToSamples(pcm), o.SampleRate, o.ResampleTo)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resample %d -> %d: %w", o.SampleRate, o.ResampleTo, err)
+		}
+		pcm = samplesToBytes(samples)
+	}
+	if o.PadToSeconds {
+		pcm = append(pcm, make([]byte, padToSecond(int64(len(pcm)), o.outputRate()))...)
+	}
+	if o.Companding != CompandingNone {
+		pcm = compand(pcm, o.Companding)
+	}
+	return pcm, nil
+}
+
+// resampler 返回 o 使用的重采样实现
+func (o DecodeOptions) resampler() Resampler {
+	if o.Resampler != nil {
+		return o.Resampler
+	}
+	if o.ResampleQuality == ResampleHigh {
+		return firResampler{}
+	}
+	return linearResampler{}
+}
+
+// logger 返回本次调用使用的日志
+func (o DecodeOptions) logger() Logger {
+	if o.Logger != nil {
+		return o.Logger
+	}
+	return logger
+}
+
+// outputRate 返回最终输出 pcm 的采样率
+func (o DecodeOptions) outputRate() int {
+	if o.ResampleTo > 0 {
+		return o.ResampleTo
+	}
+	return o.SampleRate
+}
+
+// decodedRate 返回按 o 解码得到的 pcm 的采样率, 以 dll 实际输出的采样率为准
+func (o DecodeOptions) decodedRate(info DecodeInfo) int {
+	if info.EffectiveSampleRate > 0 && o.ResampleTo <= 0 {
+		return info.EffectiveSampleRate
+	}
+	return o.outputRate()
+}
+
+// DecodeStream 按 opts 解码, 将每帧 pcm 解码后立即写入 out, 不在内存中累积
+// 以 footer(长度为负) 或恰好在 block 边界处的 EOF 作为结束(微信导出的文件没有 footer)
+func (s *silk) DecodeStream(out io.Writer, src io.Reader, opts DecodeOptions) error {
+	return s.DecodeStreamContext(context.Background(), out, src, opts)
+}
+
+// DecodeStreamContext 同 DecodeStream, 每解码一帧前检查 ctx, 取消时返回 ctx.Err()
+// dll 的 Decode 调用本身无法中断, 默认只能在帧之间响应取消, 见 DecodeOptions.AbandonOnCancel
+func (s *silk) DecodeStreamContext(ctx context.Context, out io.Writer, src io.Reader, opts DecodeOptions) error {
+	_, err := s.DecodeStreamInfo(ctx, out, src, opts)
+	return err
+}
+
+// DecodeStreamInfo 同 DecodeStreamContext, 同时返回解码统计信息, 出错时也返回已统计的部分
+func (s *silk) DecodeStreamInfo(ctx context.Context, out io.Writer, src io.Reader, opts DecodeOptions) (info DecodeInfo, err error) {
+	if opts, err = s.options(opts); err != nil {
+		return info, err
+	}
+	if err = opts.checkStreamable(); err != nil {
+		return info, err
+	}
+	info.SampleRate = opts.SampleRate
+	logger := opts.logger()
+	var callDurations []time.Duration
+	if opts.Profile {
+		defer func() { info.Profile = newDecodeProfile(callDurations) }()
+	}
+	if !opts.Deadline.IsZero() {
+		// 让 AbandonOnCancel 的等待也受 Deadline 约束
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, opts.Deadline)
+		defer cancel()
+	}
+	// ctxErr 返回 ctx 结束的原因, Deadline 先到时返回 ErrDeadlineExceeded
+	ctxErr := func() error {
+		if !opts.Deadline.IsZero() && !time.Now().Before(opts.Deadline) {
+			return ErrDeadlineExceeded
+		}
+		return ctx.Err()
+	}
+	if opts.FrameDumpDir != "" {
+		if err = prepareDumpDir(opts.FrameDumpDir); err != nil {
+			return info, err
+		}
+	}
+	var hasher = crc32.NewIEEE()
+	if opts.HashInput {
+		src = io.TeeReader(src, hasher)
+	}
+	var counter = &CountingReader{R: src}
+	var input = bufio.NewReaderSize(counter, opts.ReadBufferSize)
+	// reader 读取 block 序列, 通常就是 input, 有 TLEN 长度表时为按表生成内联长度前缀的 reader
+	var reader = input
+	// offset 返回当前已消费的输入字节数, bufio 预读而未消费的部分不算
+	offset := func() int64 { return counter.N - int64(input.Buffered()) }
+	/* Check Silk header */
+	if opts.TolerateTextCorruption {
+		if err := skipTextPrefix(reader, logger); err != nil {
+			return info, err
+		}
+	}
+	if err := checkHeader(reader, opts.LeadingByte, logger); err != nil {
+		return info, err
+	}
+	opts.emit(EventHeaderParsed, offset())
+	if opts.StrictMode && offset() != int64(HeaderLen) {
+		return info, fmt.Errorf("%w: header ends at offset %d", ErrLeadingByte, offset())
+	}
+	reader, ext, err := readHeaderExt(input, opts.ReadBufferSize)
+	if err != nil {
+		return info, err
+	}
+	if opts.StrictMode && len(ext) > 0 {
+		return info, fmt.Errorf("%w: %s", ErrExtendedHeader, strings.Join(ext, ", "))
+	}
+	var order = opts.LengthByteOrder
+	if reader != input {
+		order = binary.LittleEndian // 长度前缀由长度表生成
+	}
+	if order == nil {
+		order = detectByteOrder(reader, logger)
+		if opts.StrictMode && order == binary.BigEndian {
+			return info, ErrBigEndianLengths
+		}
+	}
+	var blockIndex, leadingZeros, zeroOutputs int
+	var written int64 // 已写入 out 的 pcm 字节数
+	// write 写入 out 并累计 written, 写入前检查 MaxPCMBytes
+	write := func(p []byte) error {
+		if opts.MaxPCMBytes > 0 && written+int64(len(p)) > int64(opts.MaxPCMBytes) {
+			return fmt.Errorf("%w: limit %d bytes, at block %d", ErrOutputTooLarge, opts.MaxPCMBytes, blockIndex)
+		}
+		_, err := out.Write(p)
+		written += int64(len(p))
+		return err
+	}
+	lib := s.lib()
+	if opts.TraceProcs {
+		lib = traceNative{lib, logger}
+	}
+	handle, err := s.openDecoder(lib, opts)
+	if err != nil {
+		return info, err
+	}
+	defer func() {
+		if handle != 0 {
+			lib.closeDecoder(handle)
+		}
+	}()
+	info.EffectiveSampleRate = effectiveSampleRate(lib, handle, opts.SampleRate)
+	if info.EffectiveSampleRate != opts.SampleRate {
+		if opts.StrictMode {
+			return info, fmt.Errorf("%w: requested %d, got %d", ErrSampleRateOverridden, opts.SampleRate, info.EffectiveSampleRate)
+		}
+		// 之后的滤波、补齐等都按 dll 实际输出的采样率计算
+		logger.Warn("silk dll uses sample rate %d instead of requested %d", info.EffectiveSampleRate, opts.SampleRate)
+		opts.SampleRate = info.EffectiveSampleRate
+	}
+	opts.emit(EventRateDetected, int64(opts.SampleRate))
+	if opts.rateDetected != nil {
+		opts.rateDetected(opts.SampleRate)
 	}
 	// in 对应 C 源码中 payload(SKP_uint8 数组), buf 对应 out(SKP_int16 数组)
-	var in = make([]byte, 1024) // Decoder.c 中 MAX_BYTES_PER_FRAME 和 Encoder.c 不一样哦
+	// 被 AbandonOnCancel 放弃的调用在返回后仍会写入 in/buf, 此时不能使用调用方的 Alloc(可能归还到内存池)
+	scratch := opts.alloc
+	if opts.AbandonOnCancel {
+		scratch = func(n int) []byte { return make([]byte, n) }
+	}
+	var in = scratch(1024) // Decoder.c 中 MAX_BYTES_PER_FRAME 和 Encoder.c 不一样哦
 	// 20ms FRAME_LENGTH_MS=20 MAX_API_FS_KHZ=48
 	var frameSize = (FRAME_LENGTH_MS * MAX_API_FS_KHZ) << 1
 	// frameSize 个 SKP_int16，这里是 []byte 所以 *2
-	var buf = make([]byte, frameSize*2) // 相当于 [frameSize]int16 大小
+	var buf = scratch(frameSize * 2) // 相当于 [frameSize]int16 大小
+	var gain = math.Pow(10, opts.Gain/20)
+	var frameBytes = opts.SampleRate * FRAME_LENGTH_MS / 1000 * 2 // 单声道一帧 pcm 的字节数
+	var dtxSilence []byte
+	if opts.EmitDtxSilence {
+		dtxSilence = SilencePCM(FRAME_LENGTH_MS*time.Millisecond, opts.SampleRate, 1)
+	}
+	var hpf *highPass
+	if opts.HighPassFilter > 0 {
+		hpf = newHighPass(opts.HighPassFilter, opts.SampleRate)
+	}
+	var gainControl *agc
+	if opts.AGC {
+		gainControl = newAGC(opts.AGCOptions, opts.SampleRate)
+	}
+	decodeFrame := func(n int, nByte int16) (int, error) {
+		if opts.Profile {
+			start := time.Now()
+			defer func() { callDurations = append(callDurations, time.Since(start)) }()
+		}
+		if !opts.AbandonOnCancel {
+			return lib.decode(handle, in[:n], n, buf, nByte)
+		}
+		length, abandoned, err := s.decodeWatched(ctx, lib, handle, in[:n], buf, nByte)
+		if abandoned {
+			logger.Warn("abandon blocking decode call on block %d, decoder handle leaked", blockIndex)
+			handle = 0 // 调用仍在进行, 不能关闭
+			return 0, ctxErr()
+		}
+		return length, err
+	}
 	for {
+		if err = ctxErr(); err != nil {
+			return info, err
+		}
+		if opts.progress != nil {
+			opts.progress(offset())
+		}
 		blockIndex++
 		var nByte int16 // 先读取 block 大小, 占两个字节，用 int16 接收
-		err = binary.Read(reader, binary.LittleEndian, &nByte)
+		err = binary.Read(reader, order, &nByte)
 		if err != nil {
 			if errors.Is(err, io.EOF) {
+				if opts.StrictMode || opts.requireFooter {
+					return info, fmt.Errorf("%w: stream ends at offset %d", ErrMissingFooter, offset())
+				}
 				err = nil
 				break
 			}
-			return nil, fmt.Errorf("failed to read block size: %w", err)
+			if errors.Is(err, io.ErrUnexpectedEOF) {
+				// 长度前缀只剩 1 字节, 与恰好在 block 边界处的 EOF 区分开
+				return info, fmt.Errorf("%w: 1 trailing byte where block %d size was expected", ErrTruncatedStream, blockIndex)
+			}
+			return info, fmt.Errorf("failed to read block size at offset %d: %w", offset(), err)
 		}
 		if nByte < 0 {
-			break // 是 footer 部分, 没有 block 内容
+			// 是 footer 部分, 没有 block 内容
+			trailer := opts.Trailer
+			if opts.StrictMode && trailer == TrailerLeave {
+				trailer = TrailerStrict
+			}
+			if err = checkTrailer(reader, trailer); err != nil {
+				return info, fmt.Errorf("after footer at offset %d: %w", offset(), err)
+			}
+			opts.emit(EventFooter, offset())
+			break
+		}
+		// DTX 静音帧同样计入, 避免无限长的 0 长度 block 绕过限制
+		if opts.MaxFrames > 0 && blockIndex > opts.MaxFrames {
+			return info, fmt.Errorf("%w: limit %d", ErrMaxFramesExceeded, opts.MaxFrames)
+		}
+		if nByte == 0 {
+			if opts.StrictMode {
+				return info, fmt.Errorf("%w: block %d at offset %d", ErrZeroLengthBlock, blockIndex, offset()-2)
+			}
+			if blockIndex == 1 && !opts.leadingDtx {
+				// 部分导出工具在文件头和第一帧之间填充了 0, 跳过且不计入 block
+				if leadingZeros++; leadingZeros > maxLeadingZeroBlocks {
+					return info, fmt.Errorf("too many zero-length blocks before first frame at offset %d", offset())
+				}
+				blockIndex--
+				continue
+			}
+			info.DtxFrames++
+			if opts.EmitDtxSilence {
+				if err = write(dtxSilence); err != nil {
+					return info, err
+				}
+			}
+			continue // 没有内容可以解码
 		}
 		if int(nByte) > len(in) { // 兜底 or 报错?
 			in = make([]byte, nByte)
 		}
 		// 再读取 block 内容，长度就是 nByte
+		// 声明了长度却读不满(包括恰好在长度前缀之后结束)都是截断, 不能当作正常结束
 		n, err := io.ReadFull(reader, in[:nByte])
 		if err != nil {
-			if errors.Is(err, io.EOF) {
-				err = nil
-				break
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+				return info, fmt.Errorf("%w: block %d declares %d bytes, got %d", ErrTruncatedStream, blockIndex, nByte, n)
 			}
-			return nil, fmt.Errorf("failed to read block: %w", err)
+			return info, fmt.Errorf("failed to read block %d at offset %d: %w", blockIndex, offset(), err)
 		}
-		if n != int(nByte) {
-			return nil, fmt.Errorf("invalid block")
+		length, err := decodeFrame(n, nByte)
+		if err != nil && handle != 0 && blockIndex == 1 && opts.RetryFirstFrame {
+			logger.Warn("failed to decode first frame, recreate decoder and retry: %+v", err)
+			lib.closeDecoder(handle)
+			handle = 0
+			if handle, err = s.openDecoder(lib, opts); err == nil {
+				length, err = decodeFrame(n, nByte)
+			}
 		}
-		length, err := s.decode(handle, in[:n], n, buf, nByte)
 		if err != nil {
-			return nil, err
+			return info, err
 		}
-		_, err = out.Write(buf[:length])
-		if err != nil {
-			return nil, err
+		if length%2 != 0 {
+			if opts.OddLength == OddLengthError {
+				return info, fmt.Errorf("%w: block %d decoded to %d bytes", ErrOddFrameLength, blockIndex, length)
+			}
+			logger.Warn("block %d decoded to odd length %d, drop the last byte", blockIndex, length)
+			length--
+		}
+		if length == 0 {
+			// 一般是 dll 与采样率不匹配, 明确报错而不是得到一个空文件
+			if zeroOutputs++; zeroOutputs >= maxZeroOutputFrames {
+				return info, fmt.Errorf("%w: %d consecutive frames decoded to 0 samples at block %d, sample rate %d",
+					ErrNoDecoderOutput, zeroOutputs, blockIndex, opts.SampleRate)
+			}
+			continue
+		}
+		zeroOutputs = 0
+		// 一个 packet 可以包含多帧(40/60ms), 长度本身无法区分多帧和多声道, 只在 DownmixToMono 时按多声道处理
+		channels := 1
+		if opts.DownmixToMono {
+			channels = frameChannels(length, frameBytes*s.framesPerPacket())
+		}
+		if info.Channels == 0 {
+			info.Channels = channels
 		}
+		if channels != info.Channels {
+			return info, fmt.Errorf("%w: block %d decoded to %d bytes, first frame had %d channels",
+				ErrUnexpectedChannels, blockIndex, length, info.Channels)
+		}
+		if channels == 0 {
+			return info, fmt.Errorf("%w: block %d decoded to %d bytes, mono frame is %d bytes",
+				ErrUnexpectedChannels, blockIndex, length, frameBytes)
+		}
+		if channels != 1 {
+			length = downmix(buf[:length], channels)
+		}
+		if hpf != nil {
+			hpf.process(buf[:length])
+		}
+		if gainControl != nil {
+			gainControl.process(buf[:length])
+		}
+		if opts.Gain != 0 {
+			applyGain(buf[:length], gain)
+		}
+		frame := buf[:length]
+		if opts.FrameProcessor != nil {
+			frame = samplesToBytes(opts.FrameProcessor(info.Frames, bytesToSamples(frame)))
+		}
+		if opts.FrameDumpDir != "" && info.Frames < maxDumpFrames {
+			if err = dumpFrame(opts.FrameDumpDir, blockIndex, frame, opts.SampleRate); err != nil {
+				return info, err
+			}
+		}
+		if err = write(frame); err != nil {
+			return info, err
+		}
+		info.Samples += len(frame) / 2
+		info.Clipped += countClipped(frame)
+		if info.Frames++; info.Frames == 1 {
+			opts.emit(EventFirstFrame, int64(blockIndex))
+		}
+		if opts.MaxOutputBytes > 0 && written >= int64(opts.MaxOutputBytes) {
+			info.Truncated = true
+			break
+		}
+	}
+	if opts.progress != nil {
+		opts.progress(offset())
+	}
+	if consumed := offset(); consumed > 0 {
+		info.CompressionRatio = float64(written) / float64(consumed)
+	}
+	if info.Frames == 0 && !opts.AllowEmpty {
+		// 只有 44 字节文件头的 wav 播放器会拒绝, 明确报错
+		return info, ErrNoAudio
+	}
+	if opts.ExpectedDuration > 0 && !info.Truncated {
+		tolerance := opts.DurationTolerance
+		if tolerance <= 0 {
+			tolerance = defaultDurationTolerance
+		}
+		got := time.Duration(written / 2 * int64(time.Second) / int64(opts.SampleRate))
+		if diff := got - opts.ExpectedDuration; diff > tolerance || diff < -tolerance {
+			return info, fmt.Errorf("%w: got %s, expected %s (tolerance %s)", ErrDurationMismatch, got, opts.ExpectedDuration, tolerance)
+		}
+	}
+	if opts.PadToSeconds {
+		if err = write(make([]byte, padToSecond(written, opts.SampleRate))); err != nil {
+			return info, err
+		}
+	}
+	if opts.HashInput {
+		// footer 之后剩余的数据同样计入, 使结果等于整个输入的 CRC32
+		if _, err = io.Copy(io.Discard, input); err != nil {
+			return info, fmt.Errorf("failed to read input for hash: %w", err)
+		}
+		info.InputHash = hasher.Sum32()
+	}
+	opts.emit(EventCompleted, written)
+	return info, nil
+}
+
+// openDecoder 创建解码器并按 opts 完成配置, 配置失败时关闭解码器
+func (s *silk) openDecoder(lib native, opts DecodeOptions) (uintptr, error) {
+	if err := s.checkSampleRate(opts.SampleRate); err != nil {
+		return 0, err
+	}
+	handle, err := lib.createDecoder()
+	if err != nil {
+		return 0, err
+	}
+	err = lib.setSampleRate(handle, opts.SampleRate)
+	if err == nil {
+		err = lib.setFramesPerPacket(handle, s.framesPerPacket())
 	}
-	return out.Bytes(), nil
+	if err != nil {
+		lib.closeDecoder(handle)
+		return 0, err
+	}
+	return handle, nil
 }
 
-func (s silk) createDecoder() (uintptr, error) {
-	f, err := s.dll.FindProc("CreateDecoder")
+func (s *silk) createDecoder() (uintptr, error) {
+	f, err := s.proc("CreateDecoder")
 	if err != nil {
 		return 0, err
 	}
 	handle, _, err := f.Call()
-	if err != nil && !errors.Is(err, windows.SEVERITY_SUCCESS) {
+	if callFailed(err) {
 		return 0, err
 	}
 	return handle, nil
 }
 
-func (s silk) closeDecoder(handle uintptr) error {
-	f, err := s.dll.FindProc("CloseDecoder")
+func (s *silk) closeDecoder(handle uintptr) error {
+	f, err := s.proc("CloseDecoder")
 	if err != nil {
 		return err
 	}
 	_, _, err = f.Call(handle)
-	if err != nil && !errors.Is(err, windows.SEVERITY_SUCCESS) {
+	if callFailed(err) {
 		return err
 	}
 	return nil
 }
 
-func (s silk) setSampleRate(handle uintptr, sample int) error {
-	f, err := s.dll.FindProc("setSampleRate")
+func (s *silk) setSampleRate(handle uintptr, sample int) error {
+	f, err := s.proc("setSampleRate")
 	if err != nil {
 		return err
 	}
 	_, _, err = f.Call(handle, uintptr(sample))
-	if err != nil && !errors.Is(err, windows.SEVERITY_SUCCESS) {
+	if callFailed(err) {
 		return err
 	}
 	return nil
 }
 
-func (s silk) setFramesPerPacket(handle uintptr, perPacket int) error {
-	f, err := s.dll.FindProc("setFramesPerPacket")
+func (s *silk) setFramesPerPacket(handle uintptr, perPacket int) error {
+	f, err := s.proc("setFramesPerPacket")
 	if err != nil {
 		return err
 	}
 	_, _, err = f.Call(handle, uintptr(perPacket))
-	if err != nil && !errors.Is(err, windows.SEVERITY_SUCCESS) {
+	if callFailed(err) {
 		return err
 	}
 	return nil
 }
 
-func (s silk) decode(handle uintptr, inData []byte, inDataLength int, outData []byte, outDataLength int16) (int, error) {
-	f, err := s.dll.FindProc("Decode")
+// decodeWatched 在单独的 goroutine 中调用 decode, ctx 取消时不再等待, 返回 abandoned=true
+// 被放弃的调用仍在使用 handle 和 inData/outData, 调用方之后不能再关闭或复用它们
+func (s *silk) decodeWatched(ctx context.Context, lib native, handle uintptr, inData []byte, outData []byte, outDataLength int16) (length int, abandoned bool, err error) {
+	type result struct {
+		length int
+		err    error
+	}
+	done := make(chan result, 1)
+	go func() {
+		n, err := lib.decode(handle, inData, len(inData), outData, outDataLength)
+		done <- result{n, err}
+	}()
+	select {
+	case r := <-done:
+		return r.length, false, r.err
+	case <-ctx.Done():
+		return 0, true, ctx.Err()
+	}
+}
+
+// getSampleRate 调用 getSampleRate(void *handle), 返回解码器实际使用的输出采样率
+func (s *silk) getSampleRate(handle uintptr) (int, error) {
+	f, err := s.proc("getSampleRate")
+	if err != nil {
+		return 0, err
+	}
+	ret, _, err := f.Call(handle)
+	if callFailed(err) {
+		return 0, err
+	}
+	return int(int32(ret)), nil
+}
+
+// decode 调用 Decode(void *handle, SKP_uint8 *in, int inLen, SKP_int16 *out, SKP_int16 *outLen)
+// 所有参数都按指针宽度的 uintptr 传递, int 参数由被调方取低 32 位, x64 与 AAPCS64(arm64) 下一致;
+// outLen 是 SKP_int16 指针, 这里对应 int16 变量的地址, Call 会让该变量逃逸到堆上, 调用期间不会移动
+func (s *silk) decode(handle uintptr, inData []byte, inDataLength int, outData []byte, outDataLength int16) (int, error) {
+	f, err := s.proc("Decode")
 	if err != nil {
 		return 0, err
 	}
-	_, _, err = f.Call(handle, uintptr(unsafe.Pointer(&inData[0])), uintptr(inDataLength), uintptr(unsafe.Pointer(&outData[0])), uintptr(unsafe.Pointer(&outDataLength)))
-	if err != nil && !errors.Is(err, windows.SEVERITY_SUCCESS) {
+	ret, _, err := f.Call(handle, uintptr(unsafe.Pointer(&inData[0])), uintptr(inDataLength), uintptr(unsafe.Pointer(&outData[0])), uintptr(unsafe.Pointer(&outDataLength)))
+	if callFailed(err) {
 		return 0, err
 	}
+	// Decode 返回 SKP_Silk_SDK_Decode 的结果, 负数表示失败
+	if code := int32(ret); code < 0 {
+		return 0, fmt.Errorf("failed to decode frame, code=%d", code)
+	}
 	return int(outDataLength * 2), nil
 }
 
@@ -221,76 +1039,53 @@ func (s silk) decode(handle uintptr, inData []byte, inDataLength int, outData []
 //
 //numchannel:1=单声道，2=多声道
 func pcmToWav(dst []byte, numchannel int, saplerate int) (resDst []byte) {
-	byteDst := dst
-	longSampleRate := saplerate
-	byteRate := 16 * saplerate * numchannel / 8
-	totalAudioLen := len(byteDst)
-	totalDataLen := totalAudioLen + 36
-	var header = make([]byte, 44)
-	// RIFF/WAVE header
-	header[0] = 'R'
-	header[1] = 'I'
-	header[2] = 'F'
-	header[3] = 'F'
-	header[4] = byte(totalDataLen & 0xff)
-	header[5] = byte((totalDataLen >> 8) & 0xff)
-	header[6] = byte((totalDataLen >> 16) & 0xff)
-	header[7] = byte((totalDataLen >> 24) & 0xff)
-	//WAVE
-	header[8] = 'W'
-	header[9] = 'A'
-	header[10] = 'V'
-	header[11] = 'E'
-	// 'fmt ' chunk
-	header[12] = 'f'
-	header[13] = 'm'
-	header[14] = 't'
-	header[15] = ' '
-	// 4 bytes: size of 'fmt ' chunk
-	header[16] = 16
-	header[17] = 0
-	header[18] = 0
-	header[19] = 0
-	// format = 1
-	header[20] = 1
-	header[21] = 0
-	header[22] = byte(numchannel)
-	header[23] = 0
-	header[24] = byte(longSampleRate & 0xff)
-	header[25] = byte((longSampleRate >> 8) & 0xff)
-	header[26] = byte((longSampleRate >> 16) & 0xff)
-	header[27] = byte((longSampleRate >> 24) & 0xff)
-	header[28] = byte(byteRate & 0xff)
-	header[29] = byte((byteRate >> 8) & 0xff)
-	header[30] = byte((byteRate >> 16) & 0xff)
-	header[31] = byte((byteRate >> 24) & 0xff)
-	// block align
-	header[32] = byte(2 * 16 / 8)
-	header[33] = 0
-	// bits per sample
-	header[34] = 16
-	header[35] = 0
-	//data
-	header[36] = 'd'
-	header[37] = 'a'
-	header[38] = 't'
-	header[39] = 'a'
-	header[40] = byte(totalAudioLen & 0xff)
-	header[41] = byte((totalAudioLen >> 8) & 0xff)
-	header[42] = byte((totalAudioLen >> 16) & 0xff)
-	header[43] = byte((totalAudioLen >> 24) & 0xff)
-
-	headerDst := header
-	resDst = append(headerDst, dst...)
+	header := make([]byte, wavHeaderLen, wavHeaderLen+len(dst))
+	putWavHeader(header, len(dst), numchannel, saplerate)
+	resDst = append(header, dst...)
 	return
 }
 
 func SilkToWav(src io.Reader) (io.Reader, error) {
-	decoder := NewSilkDecoder()
-	data, err := decoder.Decode(src)
+	decoder := newDecoder()
+	if err := decoder.Configure(Config{SampleRate: 16000, Channels: 1}); err != nil {
+		return nil, err
+	}
+	data, info, err := decoder.DecodeWithInfo(src, DecodeOptions{})
 	if err != nil {
 		return nil, err
 	}
-	rData := pcmToWav(data, 2, 16000)
+	wavOpts := decoder.WavOptions()
+	wavOpts.SampleRate = info.EffectiveSampleRate
+	rData, _ := PCMToWav(data, wavOpts)
 	return bytes.NewReader(rData), nil
 }
+
+// Validate 完整解码 src 但丢弃输出, 可解码到 footer 时返回 nil, 否则返回具体错误
+// 用于上传时确认文件可以正常解码, 不占用 pcm 大小的内存. 解码时可以容忍的缺少 footer 在这里返回 ErrMissingFooter
+func Validate(src io.Reader) error {
+	opts := DecodeOptions{}
+	opts.requireFooter = true
+	return newDecoder().DecodeStream(io.Discard, src, opts)
+}
+
+// DecodeReadSeeker 按 opts 完整解码, 返回可 seek 的 pcm reader 和输出采样率, 用于播放器拖动进度
+func DecodeReadSeeker(src io.Reader, opts DecodeOptions) (io.ReadSeeker, int, error) {
+	opts = opts.withDefaults()
+	pcm, info, err := newDecoder().DecodeWithInfo(src, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	return bytes.NewReader(pcm), opts.decodedRate(info), nil
+}
+
+// DecodeTee 将 src 解码为 pcm 写入 dst, 同时把读取到的原始 silk 数据原样写入 silkDst, 只读取一次 src
+// 解码结束后 src 中剩余的数据(footer 之后)也会写入 silkDst, silkDst 得到与输入完全相同的字节
+func DecodeTee(dst io.Writer, silkDst io.Writer, src io.Reader, opts DecodeOptions) error {
+	if err := newDecoder().DecodeStream(dst, io.TeeReader(src, silkDst), opts); err != nil {
+		return err
+	}
+	if _, err := io.Copy(silkDst, src); err != nil {
+		return fmt.Errorf("failed to copy remaining silk data: %w", err)
+	}
+	return nil
+}
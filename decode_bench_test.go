@@ -0,0 +1,122 @@
+package silk
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+)
+
+// benchStreams 小文件约 1 秒(50 帧), 大文件约 1 分钟(3000 帧)
+var benchStreams = []struct {
+	name   string
+	frames int
+}{
+	{"small", 50},
+	{"large", 3000},
+}
+
+// cachedScratch 模拟解码器结构体内缓存的缓冲区: 按请求的长度保存, 之后的每次解码直接复用
+type cachedScratch struct {
+	bufs map[int][]byte
+}
+
+func (c *cachedScratch) alloc(n int) []byte {
+	if buf, ok := c.bufs[n]; ok {
+		return buf
+	}
+	if c.bufs == nil {
+		c.bufs = make(map[int][]byte)
+	}
+	buf := make([]byte, n)
+	c.bufs[n] = buf
+	return buf
+}
+
+// poolScratch 调用方通过 DecodeOptions.Alloc 提供的缓冲区, 解码返回后归还到 sync.Pool
+type poolScratch struct {
+	pool  sync.Pool
+	taken [][]byte
+}
+
+func (p *poolScratch) alloc(n int) []byte {
+	if v, ok := p.pool.Get().(*[]byte); ok && cap(*v) >= n {
+		buf := (*v)[:n]
+		p.taken = append(p.taken, buf)
+		return buf
+	}
+	buf := make([]byte, n)
+	p.taken = append(p.taken, buf)
+	return buf
+}
+
+func (p *poolScratch) release() {
+	for _, buf := range p.taken {
+		buf := buf
+		p.pool.Put(&buf)
+	}
+	p.taken = p.taken[:0]
+}
+
+func BenchmarkDecodeBuffers(b *testing.B) {
+	for _, bs := range benchStreams {
+		stream := withFooter(buildStream(nil, payloads(bs.frames, 40)...))
+		decoder := fakeDecoder(newFakeNative())
+		run := func(b *testing.B, opts DecodeOptions, after func()) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(stream)))
+			for i := 0; i < b.N; i++ {
+				if _, err := decoder.DecodeStreamInfo(context.Background(), io.Discard, bytes.NewReader(stream), opts); err != nil {
+					b.Fatal(err)
+				}
+				if after != nil {
+					after()
+				}
+			}
+		}
+		b.Run(bs.name+"/make", func(b *testing.B) {
+			run(b, DecodeOptions{}, nil)
+		})
+		b.Run(bs.name+"/cached", func(b *testing.B) {
+			cache := &cachedScratch{}
+			run(b, DecodeOptions{Alloc: cache.alloc}, nil)
+		})
+		b.Run(bs.name+"/alloc", func(b *testing.B) {
+			pool := &poolScratch{}
+			run(b, DecodeOptions{Alloc: pool.alloc}, pool.release)
+		})
+	}
+}
+
+// readCounter 统计 Read 调用次数, 代表文件/网络输入时的系统调用数
+type readCounter struct {
+	r     io.Reader
+	reads int
+}
+
+func (c *readCounter) Read(p []byte) (int, error) {
+	c.reads++
+	return c.r.Read(p)
+}
+
+func BenchmarkDecodeReadBufferSize(b *testing.B) {
+	stream := withFooter(buildStream(nil, payloads(3000, 40)...))
+	decoder := fakeDecoder(newFakeNative())
+	for _, size := range []int{4 << 10, 16 << 10, defaultReadBufferSize, 256 << 10} {
+		b.Run(fmt.Sprintf("%dKiB", size>>10), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(stream)))
+			var reads int
+			for i := 0; i < b.N; i++ {
+				src := &readCounter{r: bytes.NewReader(stream)}
+				if _, err := decoder.DecodeStreamInfo(context.Background(), io.Discard, src, DecodeOptions{ReadBufferSize: size}); err != nil {
+					b.Fatal(err)
+				}
+				reads += src.reads
+			}
+			b.ReportMetric(float64(reads)/float64(b.N), "reads/op")
+		})
+	}
+}
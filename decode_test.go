@@ -0,0 +1,938 @@
+package silk
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// decodeFake 使用 fakeNative 解码 stream
+func decodeFake(t *testing.T, stream []byte, opts DecodeOptions) ([]byte, DecodeInfo, error) {
+	t.Helper()
+	f := newFakeNative()
+	pcm, info, err := fakeDecoder(f).DecodeWithInfo(bytes.NewReader(stream), opts)
+	f.checkLeaks(t)
+	return pcm, info, err
+}
+
+// firstSamples 返回 pcm 中每帧(frameBytes 字节)第一个采样的值, 对应 fakeNative 解码时的负载首字节
+func firstSamples(pcm []byte, frameBytes int) []int16 {
+	var out []int16
+	for i := 0; i+1 < len(pcm); i += frameBytes {
+		out = append(out, int16(binary.LittleEndian.Uint16(pcm[i:])))
+	}
+	return out
+}
+
+// wantFrames 返回 payloads(n, ...) 经 fakeNative 解码后每帧第一个采样的值
+func wantFrames(n int) []int16 {
+	out := make([]int16, n)
+	for i, p := range payloads(n, 1) {
+		out[i] = int16(p[0]) * 100
+	}
+	return out
+}
+
+func equalSamples(a, b []int16) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestDecodeByteOrder(t *testing.T) {
+	frames := payloads(6, 37)
+	little := withFooter(buildStreamOrder(binary.LittleEndian, nil, frames...))
+	big := withFooter(buildStreamOrder(binary.BigEndian, nil, frames...))
+	for _, tc := range []struct {
+		name   string
+		stream []byte
+		order  binary.ByteOrder
+	}{
+		{"little/explicit", little, binary.LittleEndian},
+		{"little/detect", little, nil},
+		{"big/explicit", big, binary.BigEndian},
+		{"big/detect", big, nil},
+	} {
+		pcm, _, err := decodeFake(t, tc.stream, DecodeOptions{LengthByteOrder: tc.order})
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if got := firstSamples(pcm, 640); !equalSamples(got, wantFrames(6)) {
+			t.Errorf("%s: frames %v, want %v", tc.name, got, wantFrames(6))
+		}
+	}
+}
+
+func TestDecodeByteOrderMismatch(t *testing.T) {
+	big := withFooter(buildStreamOrder(binary.BigEndian, nil, payloads(3, 37)...))
+	// 37 按小端读为 0x2500, 超过最大 block 长度
+	if _, _, err := decodeFake(t, big, DecodeOptions{LengthByteOrder: binary.LittleEndian}); err == nil {
+		t.Fatal("decoded a big-endian stream read as little-endian")
+	}
+}
+
+func TestDecodeByteOrderStrict(t *testing.T) {
+	big := withFooter(buildStreamOrder(binary.BigEndian, nil, payloads(3, 37)...))
+	if _, _, err := decodeFake(t, big, DecodeOptions{StrictMode: true}); !errors.Is(err, ErrBigEndianLengths) {
+		t.Fatalf("err = %v, want ErrBigEndianLengths", err)
+	}
+}
+
+func TestCountPlausibleBlocks(t *testing.T) {
+	data := buildStreamOrder(binary.BigEndian, nil, payloads(5, 20)...)[HeaderLen:]
+	if n := countPlausibleBlocks(data, binary.BigEndian); n != detectFrames {
+		t.Errorf("big-endian blocks read as big-endian: %d plausible, want %d", n, detectFrames)
+	}
+	if n := countPlausibleBlocks(data, binary.LittleEndian); n != 0 {
+		t.Errorf("big-endian blocks read as little-endian: %d plausible, want 0", n)
+	}
+}
+
+func TestDecodeLeadingZeroPadding(t *testing.T) {
+	padding := make([]byte, 2*4) // 4 个 0x0000 长度前缀
+	stream := withFooter(buildStream(padding, payloads(3, 30)...))
+	pcm, info, err := decodeFake(t, stream, DecodeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := firstSamples(pcm, 640); !equalSamples(got, wantFrames(3)) {
+		t.Fatalf("frames %v, want %v", got, wantFrames(3))
+	}
+	if info.Frames != 3 || info.DtxFrames != 0 {
+		t.Fatalf("Frames = %d, DtxFrames = %d, want 3 and 0", info.Frames, info.DtxFrames)
+	}
+}
+
+func TestDecodeTooMuchZeroPadding(t *testing.T) {
+	padding := make([]byte, 2*(maxLeadingZeroBlocks+1))
+	stream := withFooter(buildStream(padding, payloads(3, 30)...))
+	if _, _, err := decodeFake(t, stream, DecodeOptions{}); err == nil {
+		t.Fatal("decoded a stream with unbounded zero padding")
+	}
+	// 只有填充没有帧时不能无限循环
+	if _, _, err := decodeFake(t, buildStream(make([]byte, 4096)), DecodeOptions{}); err == nil {
+		t.Fatal("decoded a stream of zero padding")
+	}
+}
+
+func TestDecodeZeroPaddingStrict(t *testing.T) {
+	stream := withFooter(buildStream(make([]byte, 2), payloads(3, 30)...))
+	if _, _, err := decodeFake(t, stream, DecodeOptions{StrictMode: true}); !errors.Is(err, ErrZeroLengthBlock) {
+		t.Fatalf("err = %v, want ErrZeroLengthBlock", err)
+	}
+}
+
+func TestDecodeZeroLengthAfterFirstFrameIsDtx(t *testing.T) {
+	frames := payloads(3, 30)
+	stream := withFooter(buildStream(nil, frames[0], nil, nil, frames[1], frames[2]))
+	pcm, info, err := decodeFake(t, stream, DecodeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.DtxFrames != 2 || len(pcm) != 3*640 {
+		t.Fatalf("DtxFrames = %d, %d bytes, want 2 and %d", info.DtxFrames, len(pcm), 3*640)
+	}
+	pcm, _, err = decodeFake(t, stream, DecodeOptions{EmitDtxSilence: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pcm) != 5*640 {
+		t.Fatalf("EmitDtxSilence: %d bytes, want %d", len(pcm), 5*640)
+	}
+}
+
+func TestDecodeDtxFramesFixture(t *testing.T) {
+	// 开头 2 个填充不计入, 之后 1+2+1 个 DTX 帧
+	f := payloads(4, 30)
+	stream := withFooter(buildStream(make([]byte, 4), f[0], nil, f[1], nil, nil, f[2], f[3], nil))
+	pcm, info, err := decodeFake(t, stream, DecodeOptions{EmitDtxSilence: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.DtxFrames != 4 || info.Frames != 4 {
+		t.Fatalf("DtxFrames = %d, Frames = %d, want 4 and 4", info.DtxFrames, info.Frames)
+	}
+	w := wantFrames(4)
+	want := []int16{w[0], 0, w[1], 0, 0, w[2], w[3], 0}
+	if got := firstSamples(pcm, 640); !equalSamples(got, want) {
+		t.Fatalf("frames %v, want %v", got, want)
+	}
+}
+
+func TestDecodeTruncatedBlock(t *testing.T) {
+	full := withFooter(buildStream(nil, payloads(3, 30)...))
+	end := HeaderLen + 3*32 // 第三帧结束的位置
+	for _, tc := range []struct {
+		name string
+		cut  int
+	}{
+		{"inside payload", end - 10},
+		{"right after size prefix", end - 30},
+		{"one byte of size prefix", end - 31},
+	} {
+		_, _, err := decodeFake(t, full[:tc.cut], DecodeOptions{})
+		if !errors.Is(err, ErrTruncatedStream) {
+			t.Errorf("%s: err = %v, want ErrTruncatedStream", tc.name, err)
+		}
+	}
+}
+
+func TestDecodeCleanEOFAtBoundary(t *testing.T) {
+	// 没有 footer, 恰好在 block 边界结束, 不是截断
+	stream := buildStream(nil, payloads(3, 30)...)
+	pcm, _, err := decodeFake(t, stream, DecodeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pcm) != 3*640 {
+		t.Fatalf("decoded %d bytes, want %d", len(pcm), 3*640)
+	}
+	if _, _, err = decodeFake(t, stream, DecodeOptions{StrictMode: true}); !errors.Is(err, ErrMissingFooter) {
+		t.Fatalf("StrictMode: err = %v, want ErrMissingFooter", err)
+	}
+}
+
+func TestDecodeTrailingBytes(t *testing.T) {
+	stream := buildStream(nil, payloads(3, 4)...)
+	// 0 字节: 在 block 边界处结束
+	pcm, info, err := decodeFake(t, stream, DecodeOptions{})
+	if err != nil || len(pcm) != 3*640 || info.Frames != 3 {
+		t.Fatalf("0-byte trailer: %d bytes, %d frames, err %v", len(pcm), info.Frames, err)
+	}
+	// 1 字节: 长度前缀(或 footer)只剩一半
+	for _, b := range []byte{0x00, 0x04, 0xFF} {
+		_, info, err := decodeFake(t, append(stream[:len(stream):len(stream)], b), DecodeOptions{})
+		if !errors.Is(err, ErrTruncatedStream) || !strings.Contains(err.Error(), "1 trailing byte") {
+			t.Errorf("1-byte trailer %#02x: err = %v, want ErrTruncatedStream for 1 trailing byte", b, err)
+		}
+		if info.Frames != 3 {
+			t.Errorf("1-byte trailer %#02x: %d frames decoded before the error, want 3", b, info.Frames)
+		}
+	}
+}
+
+func TestDecodePadToSeconds(t *testing.T) {
+	stream := withFooter(buildStream(nil, payloads(75, 30)...)) // 1.5 秒
+	pcm, _, err := decodeFake(t, stream, DecodeOptions{PadToSeconds: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 2 * 16000 * 2; len(pcm) != want {
+		t.Fatalf("decoded %d bytes, want exactly 2.0 s (%d bytes)", len(pcm), want)
+	}
+	for _, b := range pcm[75*640:] {
+		if b != 0 {
+			t.Fatal("padding is not silence")
+		}
+	}
+	// 已经是整秒时不再补齐
+	stream = withFooter(buildStream(nil, payloads(50, 30)...))
+	if pcm, _, err = decodeFake(t, stream, DecodeOptions{PadToSeconds: true}); err != nil || len(pcm) != 16000*2 {
+		t.Fatalf("1.0 s clip: %d bytes, %v", len(pcm), err)
+	}
+}
+
+func TestDecodePadToSecondsWavHeader(t *testing.T) {
+	f := newFakeNative()
+	stream := withFooter(buildStream(nil, payloads(75, 30)...))
+	wav, pcm, _, err := fakeDecoder(f).DecodeBoth(bytes.NewReader(stream), DecodeOptions{SampleRate: 24000, PadToSeconds: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.checkLeaks(t)
+	if want := 2 * 24000 * 2; len(pcm) != want {
+		t.Fatalf("pcm has %d bytes, want %d", len(pcm), want)
+	}
+	if _, data := parseWav(t, wav); len(data) != len(pcm) {
+		t.Fatalf("wav data chunk has %d bytes, want %d", len(data), len(pcm))
+	}
+}
+
+func TestDefaultDecodeOptions(t *testing.T) {
+	opts := DefaultDecodeOptions()
+	if opts.SampleRate != 16000 || opts.ReadBufferSize != defaultReadBufferSize || opts.LengthByteOrder != nil || opts.LeadingByte != 0 {
+		t.Fatalf("DefaultDecodeOptions() = %+v", opts)
+	}
+	// 与 Decode 一直使用的参数一致: 16000Hz, 每包 1 帧
+	stream := append([]byte{STX}, withFooter(buildStream(nil, payloads(3, 30)...))...)
+	f := newFakeNative()
+	decoder := fakeDecoder(f)
+	plain, err := decoder.Decode(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+	withDefaults, err := decoder.DecodeWithOptions(bytes.NewReader(stream), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(plain, withDefaults) {
+		t.Fatal("Decode and DecodeWithOptions(DefaultDecodeOptions()) differ")
+	}
+	for handle := uintptr(1); handle <= 2; handle++ {
+		if f.rates[handle] != 16000 || f.packets[handle] != 1 {
+			t.Errorf("decoder %d: rate %d, frames per packet %d, want 16000 and 1", handle, f.rates[handle], f.packets[handle])
+		}
+	}
+}
+
+func TestDecodeLeadingByte(t *testing.T) {
+	stream := withFooter(buildStream(nil, payloads(3, 30)...))
+	custom := append([]byte{0x01}, stream...)
+	pcm, _, err := decodeFake(t, custom, DecodeOptions{LeadingByte: 0x01})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pcm) != 3*640 {
+		t.Fatalf("decoded %d bytes, want %d", len(pcm), 3*640)
+	}
+	if _, _, err = decodeFake(t, custom, DecodeOptions{}); !errors.Is(err, ErrInvalidHeader) {
+		t.Fatalf("without LeadingByte: err = %v, want ErrInvalidHeader", err)
+	}
+	// 设置了 LeadingByte 时仍然识别标准的 STX
+	if _, _, err = decodeFake(t, append([]byte{STX}, stream...), DecodeOptions{LeadingByte: 0x01}); err != nil {
+		t.Fatalf("STX with LeadingByte set: %v", err)
+	}
+	if _, _, err = decodeFake(t, custom, DecodeOptions{LeadingByte: 0x01, StrictMode: true}); !errors.Is(err, ErrLeadingByte) {
+		t.Fatalf("StrictMode: err = %v, want ErrLeadingByte", err)
+	}
+}
+
+func TestCheckHeaderSTX(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		data     string
+		wantErr  error
+		consumed int
+	}{
+		{"header first", Header + "\x02\x00", nil, HeaderLen},
+		{"STX then header", "\x02" + Header + "\x02\x00", nil, HeaderLen + 1},
+		// 0x02 之后不是文件头时不能丢弃, 按文件头在开头处理
+		{"STX then other data", "\x02#!SILK_V2\x00", ErrInvalidHeader, 0},
+		{"two STX", "\x02\x02" + Header, ErrInvalidHeader, 0},
+		{"STX only", "\x02", ErrTruncatedHeader, 0},
+		{"STX and short header", "\x02" + Header[:5], ErrTruncatedHeader, 0},
+	} {
+		r := bufio.NewReader(strings.NewReader(tc.data))
+		err := checkHeader(r, 0, nopLogger{})
+		if !errors.Is(err, tc.wantErr) {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.wantErr)
+			continue
+		}
+		if rest := len(tc.data) - r.Buffered(); err == nil && rest != tc.consumed {
+			t.Errorf("%s: consumed %d bytes, want %d", tc.name, rest, tc.consumed)
+		}
+	}
+}
+
+func TestDecodeTruncatedHeader(t *testing.T) {
+	_, _, err := decodeFake(t, []byte(Header[:4]), DecodeOptions{})
+	if !errors.Is(err, ErrTruncatedHeader) {
+		t.Fatalf("4-byte input: err = %v, want ErrTruncatedHeader", err)
+	}
+	if !strings.Contains(err.Error(), "read 4 bytes") {
+		t.Errorf("error %q does not report the bytes read", err)
+	}
+	if !isInvalidInput(err) {
+		t.Errorf("isInvalidInput(%v) = false", err)
+	}
+	for n := 1; n < HeaderLen; n++ {
+		if _, _, err := decodeFake(t, []byte(Header[:n]), DecodeOptions{}); !errors.Is(err, ErrTruncatedHeader) {
+			t.Errorf("%d-byte header: err = %v, want ErrTruncatedHeader", n, err)
+		}
+	}
+}
+
+func TestDecodeAMRWB(t *testing.T) {
+	// 真正的 AMR-WB: 魔数之后是 AMR 帧
+	amr := append([]byte(amrWBMagic), 0x3C, 0x48, 0x17, 0x16, 0x80, 0xE0, 0x11, 0x10, 0x00, 0x00)
+	if _, _, err := decodeFake(t, amr, DecodeOptions{}); !errors.Is(err, ErrUnsupportedAMRWB) {
+		t.Fatalf("AMR-WB: err = %v, want ErrUnsupportedAMRWB", err)
+	}
+	if _, _, err := decodeFake(t, []byte(amrWBMagic), DecodeOptions{}); !errors.Is(err, ErrUnsupportedAMRWB) {
+		t.Fatalf("magic only: err = %v, want ErrUnsupportedAMRWB", err)
+	}
+
+	// 魔数之后是(可选 0x02 加) silk 文件头: 丢弃魔数继续解码
+	silkStream := withFooter(buildStream(nil, payloads(3, 30)...))
+	for _, prefix := range []string{amrWBMagic, amrWBMagic + "\x02"} {
+		pcm, _, err := decodeFake(t, append([]byte(prefix), silkStream...), DecodeOptions{})
+		if err != nil {
+			t.Fatalf("%q prefix: %v", prefix, err)
+		}
+		if got := firstSamples(pcm, 640); !equalSamples(got, wantFrames(3)) {
+			t.Fatalf("%q prefix: frames %v, want %v", prefix, got, wantFrames(3))
+		}
+	}
+
+	// 不完整的魔数既不是 AMR-WB 也不够一个文件头
+	for n := 1; n < len(amrWBMagic); n++ {
+		if _, _, err := decodeFake(t, []byte(amrWBMagic[:n]), DecodeOptions{}); !errors.Is(err, ErrTruncatedHeader) {
+			t.Errorf("%q: err = %v, want ErrTruncatedHeader", amrWBMagic[:n], err)
+		}
+	}
+}
+
+func TestDecodeFirstBlockLengthTwo(t *testing.T) {
+	// 第一个 block 长度为 2 时长度前缀的第一个字节也是 0x02, 不能被当作 STX
+	frames := [][]byte{[]byte("ab"), []byte("cd")}
+	pcm, _, err := decodeFake(t, withFooter(buildStream(nil, frames...)), DecodeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := firstSamples(pcm, 640); !equalSamples(got, []int16{'a' * 100, 'c' * 100}) {
+		t.Fatalf("frames %v", got)
+	}
+	pcm, _, err = decodeFake(t, append([]byte{STX}, withFooter(buildStream(nil, frames...))...), DecodeOptions{})
+	if err != nil || len(pcm) != 2*640 {
+		t.Fatalf("with STX: %d bytes, %v", len(pcm), err)
+	}
+}
+
+func TestDecodeExpectedDuration(t *testing.T) {
+	stream := withFooter(buildStream(nil, payloads(50, 30)...)) // 1 秒
+	for _, tc := range []struct {
+		expected, tolerance time.Duration
+		ok                  bool
+	}{
+		{time.Second, 0, true},
+		{time.Second + 40*time.Millisecond, 0, true}, // 恰好是默认容差
+		{time.Second - 40*time.Millisecond, 0, true},
+		{time.Second + 60*time.Millisecond, 0, false},
+		{time.Second - 60*time.Millisecond, 0, false},
+		{time.Second + 100*time.Millisecond, 100 * time.Millisecond, true},
+		{time.Second + 101*time.Millisecond, 100 * time.Millisecond, false},
+		{1500 * time.Millisecond, 0, false}, // 采样率不匹配的典型情况
+	} {
+		_, _, err := decodeFake(t, stream, DecodeOptions{ExpectedDuration: tc.expected, DurationTolerance: tc.tolerance})
+		if tc.ok && err != nil {
+			t.Errorf("expected %s ± %s: %v", tc.expected, tc.tolerance, err)
+		}
+		if !tc.ok && !errors.Is(err, ErrDurationMismatch) {
+			t.Errorf("expected %s ± %s: err = %v, want ErrDurationMismatch", tc.expected, tc.tolerance, err)
+		}
+	}
+}
+
+func TestDecodeExpectedDurationBeforePadding(t *testing.T) {
+	stream := withFooter(buildStream(nil, payloads(75, 30)...)) // 1.5 秒, 补齐到 2 秒
+	_, _, err := decodeFake(t, stream, DecodeOptions{ExpectedDuration: 1500 * time.Millisecond, PadToSeconds: true})
+	if err != nil {
+		t.Fatalf("padding counted towards ExpectedDuration: %v", err)
+	}
+}
+
+func TestDecodeTee(t *testing.T) {
+	useFake(t, newFakeNative())
+	with := append(withFooter(buildStream(nil, payloads(200, 30)...)), "trailing metadata"...)
+	for name, stream := range map[string][]byte{
+		"footer":    with,
+		"no footer": buildStream(nil, payloads(3, 30)...),
+	} {
+		var pcm, raw bytes.Buffer
+		if err := DecodeTee(&pcm, &raw, bytes.NewReader(stream), DecodeOptions{ReadBufferSize: 512}); err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if !bytes.Equal(raw.Bytes(), stream) {
+			t.Errorf("%s: silkDst got %d bytes, not identical to the %d input bytes", name, raw.Len(), len(stream))
+		}
+		want, _, err := decodeFake(t, stream, DecodeOptions{})
+		if err != nil || !bytes.Equal(pcm.Bytes(), want) {
+			t.Errorf("%s: dst differs from Decode (%v)", name, err)
+		}
+	}
+}
+
+func TestDecodeTolerateTextCorruption(t *testing.T) {
+	stream := withFooter(buildStream(nil, payloads(3, 30)...))
+	for _, prefix := range []string{"\xEF\xBB\xBF", "\r\n", "\xEF\xBB\xBF\r\n", " \t\n\r\n", "\xEF\xBB\xBF\x02"} {
+		fixture := append([]byte(prefix), stream...)
+		pcm, _, err := decodeFake(t, fixture, DecodeOptions{TolerateTextCorruption: true})
+		if err != nil {
+			t.Errorf("prefix %q: %v", prefix, err)
+			continue
+		}
+		if len(pcm) != 3*640 {
+			t.Errorf("prefix %q: decoded %d bytes, want %d", prefix, len(pcm), 3*640)
+		}
+		if _, _, err = decodeFake(t, fixture, DecodeOptions{}); !errors.Is(err, ErrInvalidHeader) {
+			t.Errorf("prefix %q without the option: err = %v, want ErrInvalidHeader", prefix, err)
+		}
+	}
+}
+
+func TestDecodeTextPrefixBound(t *testing.T) {
+	stream := withFooter(buildStream(nil, payloads(3, 30)...))
+	fixture := append(bytes.Repeat([]byte("\r\n"), maxTextPrefix), stream...)
+	if _, _, err := decodeFake(t, fixture, DecodeOptions{TolerateTextCorruption: true}); !errors.Is(err, ErrInvalidHeader) {
+		t.Fatalf("err = %v, want ErrInvalidHeader beyond %d prefix bytes", err, maxTextPrefix)
+	}
+}
+
+func TestDecodeMaxPCMBytes(t *testing.T) {
+	stream := withFooter(buildStream(nil, payloads(10, 30)...))
+	_, _, err := decodeFake(t, stream, DecodeOptions{MaxPCMBytes: 5 * 640})
+	if !errors.Is(err, ErrOutputTooLarge) {
+		t.Fatalf("err = %v, want ErrOutputTooLarge", err)
+	}
+	// 恰好等于上限时可以解码
+	pcm, _, err := decodeFake(t, stream, DecodeOptions{MaxPCMBytes: 10 * 640})
+	if err != nil || len(pcm) != 10*640 {
+		t.Fatalf("at the limit: %d bytes, %v", len(pcm), err)
+	}
+}
+
+func TestDecodeMaxPCMBytesStopsEarly(t *testing.T) {
+	// 超过上限时立即停止, 不继续读取和解码之后的帧
+	f := newFakeNative()
+	stream := withFooter(buildStream(nil, payloads(100, 30)...))
+	_, err := fakeDecoder(f).DecodeWithOptions(bytes.NewReader(stream), DecodeOptions{MaxPCMBytes: 3 * 640})
+	if !errors.Is(err, ErrOutputTooLarge) {
+		t.Fatalf("err = %v, want ErrOutputTooLarge", err)
+	}
+	if f.calls != 4 {
+		t.Fatalf("decoded %d frames, want to stop at the 4th", f.calls)
+	}
+	f.checkLeaks(t)
+}
+
+func TestFrameProcessorZeroEvenFrames(t *testing.T) {
+	stream := buildStream(nil, payloads(6, 4)...)
+	var seen []int
+	opts := DecodeOptions{SampleRate: 16000, FrameProcessor: func(idx int, pcm []int16) []int16 {
+		seen = append(seen, idx)
+		if idx%2 == 0 {
+			for i := range pcm {
+				pcm[i] = 0
+			}
+		}
+		return pcm
+	}}
+	pcm, _, err := decodeFake(t, stream, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	frameBytes := 16000 * 20 / 1000 * 2
+	if len(pcm) != 6*frameBytes {
+		t.Fatalf("got %d bytes, want %d", len(pcm), 6*frameBytes)
+	}
+	want := wantFrames(6)
+	for i := range want {
+		if i%2 == 0 {
+			want[i] = 0
+		}
+	}
+	if got := firstSamples(pcm, frameBytes); !equalSamples(got, want) {
+		t.Fatalf("frames %v, want %v", got, want)
+	}
+	for i, v := range bytesToSamples(pcm[:frameBytes]) {
+		if v != 0 {
+			t.Fatalf("sample %d of zeroed frame 0 = %d", i, v)
+		}
+	}
+	for i, idx := range seen {
+		if idx != i || len(seen) != 6 {
+			t.Fatalf("processor saw frames %v, want 0..5", seen)
+		}
+	}
+}
+
+func TestFrameProcessorChangesLength(t *testing.T) {
+	stream := buildStream(nil, payloads(3, 4)...)
+	opts := DecodeOptions{SampleRate: 16000, FrameProcessor: func(idx int, pcm []int16) []int16 {
+		return pcm[:10]
+	}}
+	pcm, info, err := decodeFake(t, stream, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pcm) != 3*10*2 || info.Samples != 30 {
+		t.Fatalf("got %d bytes, %d samples, want 60 bytes, 30 samples", len(pcm), info.Samples)
+	}
+	if got, want := firstSamples(pcm, 20), wantFrames(3); !equalSamples(got, want) {
+		t.Fatalf("frames %v, want %v", got, want)
+	}
+}
+
+func TestDecodeOddLength(t *testing.T) {
+	// 第 2 帧多报告一个字节
+	oddNative := func() *fakeNative {
+		f := newFakeNative()
+		f.decodeFn = func(call int, in, out []byte, rate int) (int, error) {
+			n := fakeFrame(in, out, rate)
+			if call == 2 {
+				out[n] = 0x7F
+				n++
+			}
+			return n, nil
+		}
+		return f
+	}
+	stream := withFooter(buildStream(nil, payloads(3, 4)...))
+
+	f := oddNative()
+	logs := &recordLogger{}
+	pcm, info, err := fakeDecoder(f).DecodeWithInfo(bytes.NewReader(stream), DecodeOptions{Logger: logs})
+	f.checkLeaks(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pcm) != 3*640 || info.Samples != 3*320 {
+		t.Fatalf("round down: %d bytes, %d samples, want %d bytes", len(pcm), info.Samples, 3*640)
+	}
+	if got := firstSamples(pcm, 640); !equalSamples(got, wantFrames(3)) {
+		t.Fatalf("round down: frames %v, want %v", got, wantFrames(3))
+	}
+	if !logs.contains("odd length 641") {
+		t.Errorf("no warning for the odd length, logs: %v", logs.lines)
+	}
+
+	f = oddNative()
+	_, err = fakeDecoder(f).DecodeWithOptions(bytes.NewReader(stream), DecodeOptions{OddLength: OddLengthError})
+	f.checkLeaks(t)
+	if !errors.Is(err, ErrOddFrameLength) || !strings.Contains(err.Error(), "block 2") {
+		t.Fatalf("OddLengthError: err = %v, want ErrOddFrameLength at block 2", err)
+	}
+}
+
+func TestDecodeCompressionRatio(t *testing.T) {
+	// 1 秒 16 kHz 语音, 每帧 40 字节, 接近真实 silk 文件的码率(约 16 kbps)
+	stream := withFooter(buildStream(nil, payloads(50, 40)...))
+	pcm, info, err := decodeFake(t, stream, DecodeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := float64(len(pcm)) / float64(len(stream))
+	if info.CompressionRatio != want {
+		t.Fatalf("CompressionRatio = %v, want %d/%d = %v", info.CompressionRatio, len(pcm), len(stream), want)
+	}
+	if info.CompressionRatio < 10 || info.CompressionRatio > 40 {
+		t.Fatalf("CompressionRatio = %.1f, outside the plausible range for a voice clip", info.CompressionRatio)
+	}
+
+	// 补齐的静音不计入
+	_, padded, err := decodeFake(t, withFooter(buildStream(nil, payloads(25, 40)...)), DecodeOptions{PadToSeconds: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if padded.CompressionRatio > 20 {
+		t.Fatalf("PadToSeconds: CompressionRatio = %.1f, padding should not count", padded.CompressionRatio)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	f := newFakeNative()
+	useFake(t, f)
+	stream := withFooter(buildStream(nil, payloads(3, 30)...))
+	if err := Validate(bytes.NewReader(stream)); err != nil {
+		t.Fatalf("valid stream: %v", err)
+	}
+	for _, tc := range []struct {
+		name string
+		src  []byte
+		want error
+	}{
+		{"bad header", []byte("#!SILK_V2\x04\x00abcd\xff\xff"), ErrInvalidHeader},
+		{"truncated block", stream[:HeaderLen+20], ErrTruncatedStream},
+		{"missing footer", stream[:len(stream)-2], ErrMissingFooter},
+	} {
+		if err := Validate(bytes.NewReader(tc.src)); !errors.Is(err, tc.want) {
+			t.Errorf("%s: err = %v, want %v", tc.name, err, tc.want)
+		}
+	}
+	f.checkLeaks(t)
+}
+
+func TestDecodeMaxFrames(t *testing.T) {
+	frames := payloads(5, 30)
+	stream := withFooter(buildStream(nil, frames...))
+	if _, _, err := decodeFake(t, stream, DecodeOptions{MaxFrames: 3}); !errors.Is(err, ErrMaxFramesExceeded) {
+		t.Fatalf("5 frames, limit 3: err = %v, want ErrMaxFramesExceeded", err)
+	}
+	// 恰好达到上限不算超出, footer 和流结束都不计入
+	for _, s := range [][]byte{stream, stream[:len(stream)-2]} {
+		pcm, _, err := decodeFake(t, s, DecodeOptions{MaxFrames: 5})
+		if err != nil || len(pcm) != 5*640 {
+			t.Fatalf("5 frames, limit 5: %d bytes, err %v", len(pcm), err)
+		}
+	}
+	// 第一帧之后的零长度 block(DTX)计入, 开头的填充不计入
+	dtx := withFooter(buildStream(make([]byte, 4), frames[0], nil, nil, frames[1]))
+	if _, _, err := decodeFake(t, dtx, DecodeOptions{MaxFrames: 3}); !errors.Is(err, ErrMaxFramesExceeded) {
+		t.Fatalf("2 frames + 2 DTX, limit 3: err = %v, want ErrMaxFramesExceeded", err)
+	}
+	if _, _, err := decodeFake(t, dtx, DecodeOptions{MaxFrames: 4}); err != nil {
+		t.Fatalf("2 frames + 2 DTX, limit 4: %v", err)
+	}
+}
+
+func TestDecodeMaxOutputBytes(t *testing.T) {
+	stream := withFooter(buildStream(nil, payloads(5, 30)...))
+	// 写完达到上限的那一帧后停止
+	pcm, info, err := decodeFake(t, stream, DecodeOptions{MaxOutputBytes: 1000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pcm) != 2*640 || !info.Truncated || info.Frames != 2 {
+		t.Fatalf("cap 1000: %d bytes, %d frames, Truncated %v, want 1280 bytes, 2 frames, true", len(pcm), info.Frames, info.Truncated)
+	}
+	if got := firstSamples(pcm, 640); !equalSamples(got, wantFrames(2)) {
+		t.Fatalf("cap 1000: frames %v, want %v", got, wantFrames(2))
+	}
+
+	pcm, info, err = decodeFake(t, stream, DecodeOptions{MaxOutputBytes: 10000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pcm) != 5*640 || info.Truncated {
+		t.Fatalf("cap 10000: %d bytes, Truncated %v, want %d bytes, false", len(pcm), info.Truncated, 5*640)
+	}
+}
+
+func TestDecodeNoAudio(t *testing.T) {
+	stream := withFooter(buildStream(nil))
+	if _, _, err := decodeFake(t, stream, DecodeOptions{}); !errors.Is(err, ErrNoAudio) {
+		t.Fatalf("err = %v, want ErrNoAudio", err)
+	}
+	// AllowEmpty 时返回空结果
+	pcm, info, err := decodeFake(t, stream, DecodeOptions{AllowEmpty: true})
+	if err != nil {
+		t.Fatalf("AllowEmpty: %v", err)
+	}
+	if len(pcm) != 0 || info.Frames != 0 {
+		t.Fatalf("AllowEmpty: %d bytes, %d frames, want empty", len(pcm), info.Frames)
+	}
+}
+
+func TestDecodeNoDecoderOutput(t *testing.T) {
+	f := newFakeNative()
+	f.decodeFn = func(call int, in, out []byte, rate int) (int, error) {
+		return 0, nil
+	}
+	stream := withFooter(buildStream(nil, payloads(maxZeroOutputFrames+10, 4)...))
+	_, err := fakeDecoder(f).Decode(bytes.NewReader(stream))
+	if !errors.Is(err, ErrNoDecoderOutput) {
+		t.Fatalf("err = %v, want ErrNoDecoderOutput", err)
+	}
+	f.checkLeaks(t)
+	if f.calls != maxZeroOutputFrames {
+		t.Fatalf("stopped after %d frames, want %d", f.calls, maxZeroOutputFrames)
+	}
+
+	// 偶尔输出 0 个采样的帧只被跳过
+	f = newFakeNative()
+	f.decodeFn = func(call int, in, out []byte, rate int) (int, error) {
+		if call%2 == 0 {
+			return 0, nil
+		}
+		return fakeFrame(in, out, rate), nil
+	}
+	pcm, err := fakeDecoder(f).Decode(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (maxZeroOutputFrames + 11) / 2 * 640; len(pcm) != want {
+		t.Fatalf("%d bytes, want %d", len(pcm), want)
+	}
+}
+
+func TestDecodeDeadline(t *testing.T) {
+	stream := buildStream(nil, payloads(5, 4)...)
+
+	// 阻塞的 dll 调用在 AbandonOnCancel 时按 Deadline 放弃
+	release := make(chan struct{})
+	defer close(release)
+	f := newFakeNative()
+	f.decodeFn = func(call int, in, out []byte, rate int) (int, error) {
+		if call == 2 {
+			<-release
+		}
+		return fakeFrame(in, out, rate), nil
+	}
+	start := time.Now()
+	_, err := fakeDecoder(f).DecodeWithOptions(bytes.NewReader(stream), DecodeOptions{
+		AbandonOnCancel: true,
+		Deadline:        start.Add(50 * time.Millisecond),
+	})
+	if !errors.Is(err, ErrDeadlineExceeded) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("blocking decode: err = %v, want ErrDeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("blocking decode returned after %s", elapsed)
+	}
+	// 被放弃的 handle 不能关闭
+	if f.created != 1 || f.closed != 0 {
+		t.Fatalf("created %d decoders, closed %d, want the blocked one left open", f.created, f.closed)
+	}
+
+	// 不放弃时在下一帧之前检查 Deadline
+	f = newFakeNative()
+	f.decodeFn = func(call int, in, out []byte, rate int) (int, error) {
+		if call == 2 {
+			time.Sleep(60 * time.Millisecond)
+		}
+		return fakeFrame(in, out, rate), nil
+	}
+	_, err = fakeDecoder(f).DecodeWithOptions(bytes.NewReader(stream), DecodeOptions{Deadline: time.Now().Add(30 * time.Millisecond)})
+	if !errors.Is(err, ErrDeadlineExceeded) {
+		t.Fatalf("slow decode: err = %v, want ErrDeadlineExceeded", err)
+	}
+	f.checkLeaks(t)
+	if f.calls != 2 {
+		t.Fatalf("slow decode: %d decode calls, want 2", f.calls)
+	}
+}
+
+// stereoNative 每次 decode 输出一帧交织的双声道 pcm, 左声道 left, 右声道 right;
+// mono 返回 true 的调用改为输出单声道
+func stereoNative(left, right int16, mono func(call int) bool) *fakeNative {
+	f := newFakeNative()
+	f.decodeFn = func(call int, in, out []byte, rate int) (int, error) {
+		if mono != nil && mono(call) {
+			return fakeFrame(in, out, rate), nil
+		}
+		n := rate * FRAME_LENGTH_MS / 1000
+		for i := 0; i < n; i++ {
+			binary.LittleEndian.PutUint16(out[4*i:], uint16(left))
+			binary.LittleEndian.PutUint16(out[4*i+2:], uint16(right))
+		}
+		return 4 * n, nil
+	}
+	return f
+}
+
+func TestDecodeDownmixToMono(t *testing.T) {
+	stream := withFooter(buildStream(nil, payloads(3, 4)...))
+	f := stereoNative(1000, 3000, nil)
+	pcm, info, err := fakeDecoder(f).DecodeWithInfo(bytes.NewReader(stream), DecodeOptions{DownmixToMono: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.checkLeaks(t)
+	if len(pcm) != 3*640 || info.Channels != 2 {
+		t.Fatalf("%d bytes, %d channels, want %d bytes from 2 channels", len(pcm), info.Channels, 3*640)
+	}
+	for i, v := range bytesToSamples(pcm) {
+		if v != 2000 {
+			t.Fatalf("sample %d = %d, want the channel average 2000", i, v)
+		}
+	}
+
+	// 未开启时不检测声道, 输出原样按单声道处理
+	f = stereoNative(1000, 3000, nil)
+	pcm, err = fakeDecoder(f).Decode(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pcm) != 3*1280 {
+		t.Fatalf("without DownmixToMono: %d bytes, want %d", len(pcm), 3*1280)
+	}
+}
+
+func TestDecodeUnexpectedChannels(t *testing.T) {
+	stream := withFooter(buildStream(nil, payloads(3, 4)...))
+	// 中途从双声道变为单声道
+	f := stereoNative(1000, 3000, func(call int) bool { return call == 3 })
+	if _, err := fakeDecoder(f).DecodeWithOptions(bytes.NewReader(stream), DecodeOptions{DownmixToMono: true}); !errors.Is(err, ErrUnexpectedChannels) {
+		t.Fatalf("channel change: err = %v, want ErrUnexpectedChannels", err)
+	}
+	f.checkLeaks(t)
+
+	// 输出长度不是单声道帧长的整数倍, 无法识别声道数
+	f = newFakeNative()
+	f.decodeFn = func(call int, in, out []byte, rate int) (int, error) {
+		return fakeFrame(in, out, rate) + 320, nil
+	}
+	if _, err := fakeDecoder(f).DecodeWithOptions(bytes.NewReader(stream), DecodeOptions{DownmixToMono: true}); !errors.Is(err, ErrUnexpectedChannels) {
+		t.Fatalf("odd length: err = %v, want ErrUnexpectedChannels", err)
+	}
+	f.checkLeaks(t)
+}
+
+func TestDecodeHashInput(t *testing.T) {
+	full := withFooter(buildStream(nil, payloads(5, 30)...))
+	for _, tt := range []struct {
+		name   string
+		stream []byte
+		opts   DecodeOptions
+	}{
+		{"footer", full, DecodeOptions{}},
+		{"no footer", buildStream(nil, payloads(3, 7)...), DecodeOptions{}},
+		// footer 之后的数据和截断后未解码的部分都计入
+		{"trailer", append(append([]byte{}, full...), "trailing"...), DecodeOptions{}},
+		{"truncated", full, DecodeOptions{MaxOutputBytes: 640}},
+	} {
+		tt.opts.HashInput = true
+		_, info, err := decodeFake(t, tt.stream, tt.opts)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if want := crc32.ChecksumIEEE(tt.stream); info.InputHash != want {
+			t.Errorf("%s: InputHash = %#08x, want CRC32 %#08x", tt.name, info.InputHash, want)
+		}
+	}
+	if _, info, err := decodeFake(t, full, DecodeOptions{}); err != nil || info.InputHash != 0 {
+		t.Fatalf("without HashInput: InputHash = %#08x, err = %v", info.InputHash, err)
+	}
+}
+
+func TestDecodeReadSeeker(t *testing.T) {
+	useFake(t, newFakeNative())
+	stream := withFooter(buildStream(nil, payloads(5, 4)...))
+	r, rate, err := DecodeReadSeeker(bytes.NewReader(stream), DecodeOptions{SampleRate: 16000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rate != 16000 {
+		t.Fatalf("rate %d, want 16000", rate)
+	}
+	// 拖到第 3 帧读取, 再回到开头读取全部
+	if pos, err := r.Seek(2*640, io.SeekStart); err != nil || pos != 2*640 {
+		t.Fatalf("Seek = %d, %v", pos, err)
+	}
+	frame := make([]byte, 640)
+	if _, err = io.ReadFull(r, frame); err != nil {
+		t.Fatal(err)
+	}
+	if got := firstSamples(frame, 640); !equalSamples(got, wantFrames(5)[2:3]) {
+		t.Fatalf("frame after seek %v, want %v", got, wantFrames(5)[2:3])
+	}
+	if _, err = r.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+	pcm, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !equalSamples(firstSamples(pcm, 640), wantFrames(5)) {
+		t.Fatalf("frames after seeking back %v, want %v", firstSamples(pcm, 640), wantFrames(5))
+	}
+
+	// 移到开头之前的 seek 失败, 位置不变
+	if _, err = r.Seek(-640, io.SeekStart); err == nil {
+		t.Fatal("Seek before the start succeeded")
+	}
+	if pos, _ := r.Seek(0, io.SeekCurrent); pos != 5*640 {
+		t.Fatalf("position %d after the failed seek, want %d", pos, 5*640)
+	}
+
+	// 重采样后返回最终输出的采样率
+	if _, rate, err = DecodeReadSeeker(bytes.NewReader(stream), DecodeOptions{SampleRate: 16000, ResampleTo: 8000}); err != nil || rate != 8000 {
+		t.Fatalf("ResampleTo 8000: rate %d, %v", rate, err)
+	}
+	if _, _, err = DecodeReadSeeker(bytes.NewReader(stream[:HeaderLen-1]), DecodeOptions{}); err == nil {
+		t.Fatal("truncated header decoded")
+	}
+}
@@ -0,0 +1,39 @@
+//go:build !windows
+
+package silk
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+)
+
+// 其他平台无法加载 dllsilk.dll, 这里的实现只用于编译, 加载时总是返回错误;
+// 通过 native 接口替换底层调用(如测试中的 fake)后, 解码循环本身可以在任何平台上运行
+
+var errNoDLL = fmt.Errorf("silk dll is only available on windows, running on %s", runtime.GOOS)
+
+type dllModule struct{ Name string }
+
+func (*dllModule) FindProc(name string) (*dllProc, error) { return nil, errNoDLL }
+
+type dllProc struct{}
+
+func (*dllProc) Call(a ...uintptr) (r1, r2 uintptr, err error) { return 0, 0, errNoDLL }
+
+var (
+	errDLLNotFound  = errors.New("dll not found")
+	errBadExeFormat = errors.New("bad exe format")
+)
+
+func loadSystemDLL(name string) (*dllModule, error) {
+	return nil, errNoDLL
+}
+
+func extractEmbeddedDLL() (string, error) {
+	return "", errNoDLL
+}
+
+func callFailed(err error) bool {
+	return err != nil
+}
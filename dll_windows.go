@@ -0,0 +1,30 @@
+//go:build windows
+
+package silk
+
+import (
+	"errors"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+// dllModule/dllProc 加载的 dll 和解析出的导出函数
+type (
+	dllModule = syscall.DLL
+	dllProc   = syscall.Proc
+)
+
+var (
+	errDLLNotFound  error = windows.ERROR_MOD_NOT_FOUND  // 找不到 dll, 此时使用内置的副本
+	errBadExeFormat error = windows.ERROR_BAD_EXE_FORMAT // dll 与进程的架构不一致
+)
+
+func loadSystemDLL(name string) (*dllModule, error) {
+	return syscall.LoadDLL(name)
+}
+
+// callFailed 判断 Proc.Call 返回的 err 是否表示失败, 成功的调用同样返回 SEVERITY_SUCCESS
+func callFailed(err error) bool {
+	return err != nil && !errors.Is(err, windows.SEVERITY_SUCCESS)
+}
@@ -0,0 +1,27 @@
+package silk
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// maxDumpFrames DecodeOptions.FrameDumpDir 最多写出的帧数(20s), 避免写满磁盘
+const maxDumpFrames = 1000
+
+// prepareDumpDir 创建帧导出目录
+func prepareDumpDir(dir string) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create frame dump dir %s: %w", dir, err)
+	}
+	return nil
+}
+
+// dumpFrame 将一帧 pcm 写为 dir/frame_000123.wav
+func dumpFrame(dir string, blockIndex int, pcm []byte, sampleRate int) error {
+	name := filepath.Join(dir, fmt.Sprintf("frame_%06d.wav", blockIndex))
+	if err := os.WriteFile(name, pcmToWav(pcm, 1, sampleRate), 0o644); err != nil {
+		return fmt.Errorf("failed to dump frame %d: %w", blockIndex, err)
+	}
+	return nil
+}
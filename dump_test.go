@@ -0,0 +1,46 @@
+package silk
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDecodeFrameDumpDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "frames")
+	frames := payloads(3, 4)
+	// 文件按 block 序号命名, 第 2 个 block 是 DTX, 没有对应的文件
+	stream := withFooter(buildStream(nil, frames[0], nil, frames[1], frames[2]))
+	pcm, _, err := decodeFake(t, stream, DecodeOptions{FrameDumpDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := dirEntries(t, dir), "frame_000001.wav frame_000003.wav frame_000004.wav"; got != want {
+		t.Fatalf("dumped %q, want %q", got, want)
+	}
+	for i, name := range []string{"frame_000001.wav", "frame_000003.wav", "frame_000004.wav"} {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		format, frame := parseWav(t, data)
+		if format.sampleRate != 16000 || format.channels != 1 || !bytes.Equal(frame, pcm[i*640:(i+1)*640]) {
+			t.Errorf("%s: %d Hz, %d channels, %d bytes, want frame %d of the output", name, format.sampleRate, format.channels, len(frame), i)
+		}
+	}
+}
+
+func TestDecodeFrameDumpDirLimit(t *testing.T) {
+	dir := t.TempDir()
+	if _, _, err := decodeFake(t, buildStream(nil, payloads(maxDumpFrames+5, 1)...), DecodeOptions{FrameDumpDir: dir}); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != maxDumpFrames {
+		t.Fatalf("dumped %d frames, want %d", len(entries), maxDumpFrames)
+	}
+}
@@ -0,0 +1,64 @@
+package silk
+
+import (
+	"bytes"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+//go:embed bin/dllsilk.dll
+var embeddedDLL []byte
+
+var (
+	extractOnce sync.Once
+	extractPath string
+	extractErr  error
+)
+
+// extractEmbeddedDLL 将内置的 dllsilk.dll 写入临时目录并返回路径, 进程内只写一次
+// 文件名带内容哈希, 不同版本的 dll 不会互相覆盖; 已存在且内容一致时直接复用
+func extractEmbeddedDLL() (string, error) {
+	extractOnce.Do(func() {
+		sum := sha256.Sum256(embeddedDLL)
+		path := filepath.Join(os.TempDir(), "dllsilk-"+hex.EncodeToString(sum[:8])+".dll")
+		if err := writeFileOnce(path, embeddedDLL); err != nil {
+			extractErr = fmt.Errorf("failed to extract embedded dllsilk.dll to %s, deploy dllsilk.dll or use NewSilkDecoderWithDLL: %w", path, err)
+			return
+		}
+		extractPath = path
+	})
+	return extractPath, extractErr
+}
+
+// writeFileOnce 先写临时文件再改名, 避免其他进程加载到写了一半的文件
+func writeFileOnce(path string, data []byte) error {
+	if existing, err := os.ReadFile(path); err == nil && bytes.Equal(existing, data) {
+		return nil
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err = tmp.Write(data); err == nil {
+		err = tmp.Close()
+	} else {
+		tmp.Close()
+	}
+	if err != nil {
+		return err
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		// 其他进程已写入并加载了同一个文件时无法覆盖, 内容一致即可
+		if existing, readErr := os.ReadFile(path); readErr == nil && bytes.Equal(existing, data) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
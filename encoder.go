@@ -0,0 +1,91 @@
+package silk
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Encoder 将解码得到的小端序 16bit pcm 编码为某种输出格式写入 w
+// opts 描述 pcm 的采样率和声道数
+type Encoder interface {
+	Encode(w io.Writer, pcm []byte, opts WavOptions) error
+}
+
+// EncoderFunc 将普通函数适配为 Encoder
+type EncoderFunc func(w io.Writer, pcm []byte, opts WavOptions) error
+
+func (f EncoderFunc) Encode(w io.Writer, pcm []byte, opts WavOptions) error {
+	return f(w, pcm, opts)
+}
+
+var (
+	encodersMu sync.RWMutex
+	encoders   = map[string]Encoder{
+		"wav": EncoderFunc(func(w io.Writer, pcm []byte, opts WavOptions) error {
+			if _, err := WriteWavHeader(w, len(pcm), opts); err != nil {
+				return err
+			}
+			if _, err := w.Write(pcm); err != nil {
+				return err
+			}
+			_, err := WriteWavTrailer(w, len(pcm), opts)
+			return err
+		}),
+		"aiff": EncoderFunc(func(w io.Writer, pcm []byte, opts WavOptions) error {
+			opts = opts.withDefaults()
+			_, err := w.Write(pcmToAiff(pcm, opts.Channels, opts.SampleRate))
+			return err
+		}),
+	}
+)
+
+// RegisterEncoder 注册输出格式 name(不区分大小写), 供 SilkToFormat 使用
+// 用于在其他模块中提供 mp3/ogg/flac 等格式而不让本包依赖编码库. 重复注册或 enc 为 nil 时 panic
+func RegisterEncoder(name string, enc Encoder) {
+	encodersMu.Lock()
+	defer encodersMu.Unlock()
+	if enc == nil {
+		panic("silk: RegisterEncoder encoder is nil")
+	}
+	name = strings.ToLower(name)
+	if _, dup := encoders[name]; dup {
+		panic("silk: RegisterEncoder called twice for " + name)
+	}
+	encoders[name] = enc
+}
+
+// Encoders 返回已注册的输出格式
+func Encoders() []string {
+	encodersMu.RLock()
+	defer encodersMu.RUnlock()
+	names := make([]string, 0, len(encoders))
+	for name := range encoders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SilkToFormat 将silk文件的reader解码后用已注册的 name 格式编码, 内置 "wav" 和 "aiff"
+func SilkToFormat(src io.Reader, name string) (io.Reader, error) {
+	encodersMu.RLock()
+	enc, ok := encoders[strings.ToLower(name)]
+	encodersMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: %q, registered: %v", ErrUnknownFormat, name, Encoders())
+	}
+	opts := DecodeOptions{}.withDefaults()
+	pcm, info, err := newDecoder().DecodeWithInfo(src, opts)
+	if err != nil {
+		return nil, err
+	}
+	out := &bytes.Buffer{}
+	if err = enc.Encode(out, pcm, WavOptions{SampleRate: opts.decodedRate(info), Channels: 1}); err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+	return out, nil
+}
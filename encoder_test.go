@@ -0,0 +1,85 @@
+package silk
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestRegisterEncoder(t *testing.T) {
+	useFake(t, newFakeNative())
+	var gotPCM []byte
+	var gotOpts WavOptions
+	RegisterEncoder("Fake", EncoderFunc(func(w io.Writer, pcm []byte, opts WavOptions) error {
+		gotPCM, gotOpts = pcm, opts
+		_, err := fmt.Fprintf(w, "fake:%d", len(pcm))
+		return err
+	}))
+	t.Cleanup(func() {
+		encodersMu.Lock()
+		delete(encoders, "fake")
+		encodersMu.Unlock()
+	})
+	stream := withFooter(buildStream(nil, payloads(2, 30)...))
+	r, err := SilkToFormat(bytes.NewReader(stream), "FAKE")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, _ := io.ReadAll(r)
+	if string(out) != "fake:1280" || len(gotPCM) != 1280 {
+		t.Fatalf("encoded %q from %d bytes, want fake:1280", out, len(gotPCM))
+	}
+	if gotOpts.SampleRate != 16000 || gotOpts.Channels != 1 {
+		t.Fatalf("encoder opts %+v, want 16000 Hz mono", gotOpts)
+	}
+	var found bool
+	for _, n := range Encoders() {
+		found = found || n == "fake"
+	}
+	if !found {
+		t.Fatalf("Encoders() = %v, missing the registered name", Encoders())
+	}
+}
+
+func TestRegisterEncoderDuplicate(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Fatal("registering wav twice did not panic")
+		}
+	}()
+	RegisterEncoder("WAV", EncoderFunc(func(io.Writer, []byte, WavOptions) error { return nil }))
+}
+
+func TestSilkToFormatBuiltin(t *testing.T) {
+	useFake(t, newFakeNative())
+	stream := withFooter(buildStream(nil, payloads(2, 30)...))
+	r, err := SilkToFormat(bytes.NewReader(stream), "wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(r)
+	f, pcm := parseWav(t, data)
+	if f.sampleRate != 16000 || len(pcm) != 1280 {
+		t.Fatalf("wav %d Hz with %d bytes of pcm", f.sampleRate, len(pcm))
+	}
+	if _, err = SilkToFormat(bytes.NewReader(stream), "mp3"); !errors.Is(err, ErrUnknownFormat) {
+		t.Fatalf("unregistered format: err = %v, want ErrUnknownFormat", err)
+	}
+}
+
+func TestSilkToFormatEncoderError(t *testing.T) {
+	useFake(t, newFakeNative())
+	boom := errors.New("boom")
+	RegisterEncoder("broken", EncoderFunc(func(io.Writer, []byte, WavOptions) error { return boom }))
+	t.Cleanup(func() {
+		encodersMu.Lock()
+		delete(encoders, "broken")
+		encodersMu.Unlock()
+	})
+	stream := withFooter(buildStream(nil, payloads(1, 30)...))
+	if _, err := SilkToFormat(bytes.NewReader(stream), "broken"); !errors.Is(err, boom) {
+		t.Fatalf("err = %v, want the encoder error", err)
+	}
+}
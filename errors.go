@@ -0,0 +1,67 @@
+package silk
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrInvalidHeader 文件头不是 #!SILK_V3
+	ErrInvalidHeader = errors.New("invalid file header")
+	// ErrAlreadyWav 输入已经是 wav(RIFF/WAVE), 不需要转换
+	ErrAlreadyWav = errors.New("input is already wav")
+	// ErrUnsupportedAMRWB 输入是 AMR-WB(#!AMR-WB 开头)而不是 silk
+	ErrUnsupportedAMRWB = errors.New("input is AMR-WB, not silk")
+	// ErrTruncatedHeader 输入在文件头读完之前结束, 不足 9 字节
+	ErrTruncatedHeader = errors.New("silk header truncated")
+	// ErrTruncatedStream 流在 block 中间结束, 声明的长度大于实际剩余的字节
+	ErrTruncatedStream = errors.New("silk stream truncated")
+	// ErrMaxFramesExceeded block 数超过 DecodeOptions.MaxFrames
+	ErrMaxFramesExceeded = errors.New("silk stream exceeds max frames")
+	// ErrNoDecoderOutput dll 连续多帧解码成功却没有输出任何采样
+	ErrNoDecoderOutput = errors.New("silk dll produced no output")
+	// ErrDeadlineExceeded 超过 DecodeOptions.Deadline, 同时满足 errors.Is(err, context.DeadlineExceeded)
+	ErrDeadlineExceeded = fmt.Errorf("silk decode deadline exceeded: %w", context.DeadlineExceeded)
+	// ErrNoAudio 文件头和结尾都正常, 但没有解码出任何 pcm, 见 DecodeOptions.AllowEmpty
+	ErrNoAudio = errors.New("silk stream contains no audio")
+	// ErrUnknownFormat SilkToFormat 的输出格式没有注册
+	ErrUnknownFormat = errors.New("unknown output format")
+	// ErrDLLArchMismatch dll 与当前进程的架构(32/64 位, x86/arm)不一致
+	ErrDLLArchMismatch = errors.New("silk dll architecture does not match process")
+	// ErrUnsupportedSampleRate 当前 dll 不支持该输出采样率
+	ErrUnsupportedSampleRate = errors.New("sample rate not supported by silk dll")
+	// ErrCompandedOutput 按 16bit pcm 处理输出的函数设置了 DecodeOptions.Companding
+	ErrCompandedOutput = errors.New("companded output is not 16bit pcm")
+	// ErrWholeStreamOption 流式解码时设置了 Speed、ResampleTo 等只在整段解码时生效的选项
+	ErrWholeStreamOption = errors.New("option requires whole-stream decoding")
+	// ErrOddFrameLength dll 返回的输出长度不是整数个 16bit 采样, 见 DecodeOptions.OddLength
+	ErrOddFrameLength = errors.New("decoded frame length is odd")
+	// ErrUnexpectedChannels dll 输出了多声道(或无法识别声道数)的 pcm, 见 DecodeOptions.DownmixToMono
+	ErrUnexpectedChannels = errors.New("silk dll produced unexpected channel count")
+	// ErrTrailingData footer 之后还有不属于 silk 流的数据, 见 TrailerStrict
+	ErrTrailingData = errors.New("unexpected data after silk footer")
+	// ErrRingClosed 向已经 CloseWrite 的 RingPipe 写入
+	ErrRingClosed = errors.New("silk ring pipe closed")
+	// ErrDurationMismatch 解码出的时长与 DecodeOptions.ExpectedDuration 相差超过容差
+	ErrDurationMismatch = errors.New("decoded duration does not match expected")
+	// ErrOutputTooLarge 输出的 pcm 超过 DecodeOptions.MaxPCMBytes
+	ErrOutputTooLarge = errors.New("decoded pcm exceeds size limit")
+	// ErrConfigMismatch DecodeOptions 与解码器 Configure 的配置不一致
+	ErrConfigMismatch = errors.New("decode options do not match decoder config")
+
+	// 以下为 DecodeOptions.StrictMode 下不再容忍的非标准输入
+
+	// ErrLeadingByte 文件头之前有 STX 等前缀字节
+	ErrLeadingByte = errors.New("silk stream has a leading byte before header")
+	// ErrBigEndianLengths 自动识别出大端序的长度前缀
+	ErrBigEndianLengths = errors.New("silk stream uses big-endian block sizes")
+	// ErrZeroLengthBlock 长度为 0 的 block(文件头之后的填充或 DTX)
+	ErrZeroLengthBlock = errors.New("silk stream has a zero-length block")
+	// ErrMissingFooter 流在 block 边界处结束, 没有 footer
+	ErrMissingFooter = errors.New("silk stream has no footer")
+	// ErrExtendedHeader 文件头之后有 TDUR/TLEN 扩展块
+	ErrExtendedHeader = errors.New("silk stream has an extended header")
+	// ErrSampleRateOverridden dll 实际使用的采样率与请求的不同
+	ErrSampleRateOverridden = errors.New("silk dll overrode requested sample rate")
+)
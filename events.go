@@ -0,0 +1,53 @@
+package silk
+
+import "time"
+
+// EventKind DecodeEvent 的类型
+type EventKind int
+
+const (
+	// EventHeaderParsed 文件头校验通过, Value 为文件头结束处的偏移
+	EventHeaderParsed EventKind = iota + 1
+	// EventRateDetected 解码器创建完成, Value 为 dll 实际输出的采样率
+	EventRateDetected
+	// EventFirstFrame 第一帧 pcm 已写出, Value 为该帧的 block 序号(从 1 开始)
+	EventFirstFrame
+	// EventFooter 读到 footer, Value 为 footer 之后的偏移; 没有 footer 的文件不会发送
+	EventFooter
+	// EventCompleted 解码成功结束, Value 为写出的 pcm 字节数; 出错时不会发送
+	EventCompleted
+)
+
+func (k EventKind) String() string {
+	switch k {
+	case EventHeaderParsed:
+		return "header parsed"
+	case EventRateDetected:
+		return "rate detected"
+	case EventFirstFrame:
+		return "first frame"
+	case EventFooter:
+		return "footer"
+	case EventCompleted:
+		return "completed"
+	}
+	return "unknown"
+}
+
+// DecodeEvent 解码过程中的一个节点, 通过 DecodeOptions.Events 发送
+type DecodeEvent struct {
+	Kind  EventKind
+	Time  time.Time
+	Value int64 // 含义见各 EventKind
+}
+
+// emit 向 opts.Events 发送事件, channel 满时丢弃, 不阻塞解码
+func (opts DecodeOptions) emit(kind EventKind, value int64) {
+	if opts.Events == nil {
+		return
+	}
+	select {
+	case opts.Events <- DecodeEvent{Kind: kind, Time: time.Now(), Value: value}:
+	default:
+	}
+}
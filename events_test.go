@@ -0,0 +1,92 @@
+package silk
+
+import (
+	"testing"
+	"time"
+)
+
+// collectEvents 关闭 ch 并返回其中的全部事件
+func collectEvents(ch chan DecodeEvent) []DecodeEvent {
+	close(ch)
+	var events []DecodeEvent
+	for e := range ch {
+		events = append(events, e)
+	}
+	return events
+}
+
+func TestDecodeEventSequence(t *testing.T) {
+	stream := withFooter(buildStream(nil, payloads(3, 4)...))
+	ch := make(chan DecodeEvent, 16)
+	start := time.Now()
+	pcm, _, err := decodeFake(t, stream, DecodeOptions{SampleRate: 16000, Events: ch})
+	if err != nil {
+		t.Fatal(err)
+	}
+	events := collectEvents(ch)
+	want := []DecodeEvent{
+		{Kind: EventHeaderParsed, Value: int64(len(Header))},
+		{Kind: EventRateDetected, Value: 16000},
+		{Kind: EventFirstFrame, Value: 1},
+		{Kind: EventFooter, Value: int64(len(stream))},
+		{Kind: EventCompleted, Value: int64(len(pcm))},
+	}
+	if len(events) != len(want) {
+		t.Fatalf("got %d events %v, want %d", len(events), events, len(want))
+	}
+	last := start
+	for i, e := range events {
+		if e.Kind != want[i].Kind || e.Value != want[i].Value {
+			t.Errorf("event %d = %v(%d), want %v(%d)", i, e.Kind, e.Value, want[i].Kind, want[i].Value)
+		}
+		if e.Time.Before(last) {
+			t.Errorf("event %d %v at %v is earlier than the previous event", i, e.Kind, e.Time)
+		}
+		last = e.Time
+	}
+}
+
+func TestDecodeEventsWithoutFooterOrOnError(t *testing.T) {
+	stream := buildStream(nil, payloads(2, 4)...)
+	ch := make(chan DecodeEvent, 16)
+	if _, _, err := decodeFake(t, stream, DecodeOptions{Events: ch}); err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range collectEvents(ch) {
+		if e.Kind == EventFooter {
+			t.Fatal("EventFooter sent for a stream without footer")
+		}
+	}
+
+	ch = make(chan DecodeEvent, 16)
+	truncated := stream[:len(stream)-1]
+	if _, _, err := decodeFake(t, truncated, DecodeOptions{Events: ch}); err == nil {
+		t.Fatal("decoded a truncated stream")
+	}
+	for _, e := range collectEvents(ch) {
+		if e.Kind == EventCompleted {
+			t.Fatal("EventCompleted sent for a failed decode")
+		}
+	}
+}
+
+func TestDecodeEventsDropWhenFull(t *testing.T) {
+	stream := withFooter(buildStream(nil, payloads(3, 4)...))
+	ch := make(chan DecodeEvent, 1)
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := decodeFake(t, stream, DecodeOptions{Events: ch})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("decode blocked on a full events channel")
+	}
+	if events := collectEvents(ch); len(events) != 1 || events[0].Kind != EventHeaderParsed {
+		t.Fatalf("events %v, want only the first one", events)
+	}
+}
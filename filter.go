@@ -0,0 +1,93 @@
+package silk
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+)
+
+// highPass 一阶高通滤波器 y[n] = a*(y[n-1] + x[n] - x[n-1]), 用于去除直流偏移和低频噪声
+// 在帧之间保留状态, 可以逐帧处理
+type highPass struct {
+	alpha        float64
+	prevX, prevY float64
+}
+
+func newHighPass(cutoff float64, sampleRate int) *highPass {
+	rc := 1 / (2 * math.Pi * cutoff)
+	dt := 1 / float64(sampleRate)
+	return &highPass{alpha: rc / (rc + dt)}
+}
+
+// process 原地滤波小端序 16bit pcm
+func (f *highPass) process(pcm []byte) {
+	for i := 0; i+1 < len(pcm); i += 2 {
+		x := float64(int16(binary.LittleEndian.Uint16(pcm[i:])))
+		y := f.alpha * (f.prevY + x - f.prevX)
+		f.prevX, f.prevY = x, y
+		binary.LittleEndian.PutUint16(pcm[i:], uint16(clip16(y)))
+	}
+}
+
+// AGCOptions 自动增益控制的参数
+type AGCOptions struct {
+	TargetRMS float64       // 目标 RMS 电平(采样值), 为 0 时使用 3000(约 -20dBFS)
+	Attack    time.Duration // 电平上升时包络的时间常数, 为 0 时使用 10ms
+	Release   time.Duration // 电平下降时包络的时间常数, 为 0 时使用 500ms
+	MaxGain   float64       // 最大增益(dB), 避免把静音段的噪声放大太多, 为 0 时使用 20
+}
+
+func (o AGCOptions) withDefaults() AGCOptions {
+	if o.TargetRMS <= 0 {
+		o.TargetRMS = 3000
+	}
+	if o.Attack <= 0 {
+		o.Attack = 10 * time.Millisecond
+	}
+	if o.Release <= 0 {
+		o.Release = 500 * time.Millisecond
+	}
+	if o.MaxGain <= 0 {
+		o.MaxGain = 20
+	}
+	return o
+}
+
+// agc 简单的自动增益控制: 按 attack/release 跟踪信号的均方包络, 增益为目标电平与包络 RMS 之比
+// 只是基本的电平归一化, 不是响度(LUFS)标准化; 在帧之间保留状态, 可以逐帧处理
+type agc struct {
+	target            float64
+	attack, release   float64 // 包络的平滑系数
+	maxGain, envelope float64
+}
+
+func newAGC(opts AGCOptions, sampleRate int) *agc {
+	opts = opts.withDefaults()
+	coef := func(d time.Duration) float64 {
+		return math.Exp(-1 / (d.Seconds() * float64(sampleRate)))
+	}
+	return &agc{
+		target:   opts.TargetRMS,
+		attack:   coef(opts.Attack),
+		release:  coef(opts.Release),
+		maxGain:  math.Pow(10, opts.MaxGain/20),
+		envelope: opts.TargetRMS * opts.TargetRMS,
+	}
+}
+
+// process 原地处理小端序 16bit pcm
+func (a *agc) process(pcm []byte) {
+	for i := 0; i+1 < len(pcm); i += 2 {
+		x := float64(int16(binary.LittleEndian.Uint16(pcm[i:])))
+		coef := a.release
+		if x*x > a.envelope {
+			coef = a.attack
+		}
+		a.envelope = coef*a.envelope + (1-coef)*x*x
+		gain := a.maxGain
+		if rms := math.Sqrt(a.envelope); rms*a.maxGain > a.target {
+			gain = a.target / rms
+		}
+		binary.LittleEndian.PutUint16(pcm[i:], uint16(clip16(x*gain)))
+	}
+}
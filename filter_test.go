@@ -0,0 +1,123 @@
+package silk
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+// meanRMS 返回采样的均值和 RMS
+func meanRMS(s []int16) (mean, rms float64) {
+	for _, v := range s {
+		mean += float64(v)
+		rms += float64(v) * float64(v)
+	}
+	n := float64(len(s))
+	return mean / n, math.Sqrt(rms / n)
+}
+
+// addDC 在采样上叠加直流偏置
+func addDC(s []int16, dc int16) []int16 {
+	out := make([]int16, len(s))
+	for i, v := range s {
+		out[i] = v + dc
+	}
+	return out
+}
+
+func TestHighPassRemovesDC(t *testing.T) {
+	const rate = 16000
+	pcm := samplesToBytes(addDC(sineSamples(rate, 440, 5000, rate), 8000))
+	newHighPass(80, rate).process(pcm)
+	// 跳过开头的瞬态(时间常数约 2ms)
+	mean, _ := meanRMS(bytesToSamples(pcm)[rate/10:])
+	if math.Abs(mean) > 50 {
+		t.Fatalf("mean after filtering = %.1f, want about 0", mean)
+	}
+}
+
+func TestHighPassKeepsVoiceBand(t *testing.T) {
+	const rate = 16000
+	in := sineSamples(rate, 1000, 10000, rate)
+	pcm := samplesToBytes(in)
+	newHighPass(80, rate).process(pcm)
+	_, before := meanRMS(in)
+	_, after := meanRMS(bytesToSamples(pcm)[rate/10:])
+	if after < 0.95*before {
+		t.Fatalf("1 kHz RMS %.0f -> %.0f, want almost unchanged", before, after)
+	}
+}
+
+func TestHighPassAcrossFrames(t *testing.T) {
+	// 分帧处理与整段处理结果相同, 滤波状态跨帧延续
+	const rate = 16000
+	in := samplesToBytes(addDC(sineSamples(rate/5, 300, 3000, rate), 2000))
+	whole := append([]byte(nil), in...)
+	newHighPass(100, rate).process(whole)
+	framed := append([]byte(nil), in...)
+	f := newHighPass(100, rate)
+	for i := 0; i < len(framed); i += 640 {
+		f.process(framed[i : i+640])
+	}
+	if !bytes.Equal(whole, framed) {
+		t.Fatal("frame-by-frame filtering differs from filtering the whole signal")
+	}
+}
+
+func TestDecodeHighPassFilter(t *testing.T) {
+	stream := withFooter(buildStream(nil, payloads(50, 30)...))
+	f := levelsNative(6000) // 只有直流分量
+	pcm, info, err := fakeDecoder(f).DecodeWithInfo(bytes.NewReader(stream), DecodeOptions{HighPassFilter: 80})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.checkLeaks(t)
+	mean, _ := meanRMS(bytesToSamples(pcm)[info.Samples/2:])
+	if math.Abs(mean) > 1 {
+		t.Fatalf("mean of the second half = %.2f, want about 0", mean)
+	}
+}
+
+func TestAGCConverges(t *testing.T) {
+	const rate = 16000
+	quiet := sineSamples(4*rate, 200, 500, rate)
+	loud := sineSamples(4*rate, 200, 20000, rate)
+	pcm := samplesToBytes(append(append([]int16(nil), quiet...), loud...))
+	a := newAGC(AGCOptions{}, rate)
+	for i := 0; i < len(pcm); i += 640 { // 按帧处理
+		a.process(pcm[i : i+640])
+	}
+	out := bytesToSamples(pcm)
+	// 两段的输入电平相差 32dB, 稳定之后(各段的最后 1 秒)都接近目标电平
+	for name, part := range map[string][]int16{"quiet": out[3*rate : 4*rate], "loud": out[7*rate:]} {
+		_, rms := meanRMS(part)
+		if rms < 3000*0.7 || rms > 3000*1.3 {
+			t.Errorf("%s section RMS = %.0f, want about 3000", name, rms)
+		}
+	}
+}
+
+func TestAGCMaxGain(t *testing.T) {
+	const rate = 16000
+	pcm := samplesToBytes(sineSamples(rate, 200, 30, rate)) // 约 -60dBFS 的底噪
+	newAGC(AGCOptions{MaxGain: 20}, rate).process(pcm)
+	_, rms := meanRMS(bytesToSamples(pcm)[rate/2:])
+	if want := 30 / math.Sqrt2 * 10; rms > want*1.05 {
+		t.Fatalf("RMS = %.0f, want at most %.0f (+20 dB)", rms, want)
+	}
+}
+
+func TestDecodeAGC(t *testing.T) {
+	const rate = 16000
+	quiet := sineSamples(4*rate, 200, 800, rate)
+	f := samplesNative(quiet)
+	stream := withFooter(buildStream(nil, payloads(200, 30)...))
+	pcm, err := fakeDecoder(f).DecodeWithOptions(bytes.NewReader(stream), DecodeOptions{AGC: true, AGCOptions: AGCOptions{TargetRMS: 5000}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.checkLeaks(t)
+	if _, rms := meanRMS(bytesToSamples(pcm)[3*rate:]); rms < 5000*0.7 || rms > 5000*1.3 {
+		t.Fatalf("RMS = %.0f, want about 5000", rms)
+	}
+}
@@ -0,0 +1,49 @@
+package silk
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+)
+
+// DecodeFloat32 解码为 [-1, 1) 范围的 float32 采样, 同时返回采样率
+// 适合直接作为特征提取等分析的输入
+func DecodeFloat32(src io.Reader) ([]float32, int, error) {
+	opts := DecodeOptions{}.withDefaults()
+	w := &float32Writer{}
+	info, err := newDecoder().DecodeStreamInfo(context.Background(), w, src, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	return w.samples, info.EffectiveSampleRate, nil
+}
+
+// float32Writer 在解码过程中逐帧把 16bit pcm 转换为 float32, 不保留中间的 pcm
+type float32Writer struct {
+	samples []float32
+}
+
+func (w *float32Writer) Write(p []byte) (int, error) {
+	for i := 0; i+1 < len(p); i += 2 {
+		w.samples = append(w.samples, float32(int16(binary.LittleEndian.Uint16(p[i:])))/32768)
+	}
+	return len(p), nil
+}
+
+// DecodeInt32 按 opts 解码为 int32 采样(数值与 16bit pcm 相同), 同时返回采样率
+// 多段音频相加时有足够的余量, 由调用方在最后统一限幅
+func DecodeInt32(src io.Reader, opts DecodeOptions) ([]int32, int, error) {
+	opts = opts.withDefaults()
+	if err := opts.requireLinear(); err != nil {
+		return nil, 0, err
+	}
+	pcm, info, err := newDecoder().DecodeWithInfo(src, opts)
+	if err != nil {
+		return nil, 0, err
+	}
+	samples := make([]int32, len(pcm)/2)
+	for i := range samples {
+		samples[i] = int32(int16(binary.LittleEndian.Uint16(pcm[2*i:])))
+	}
+	return samples, opts.decodedRate(info), nil
+}
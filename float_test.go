@@ -0,0 +1,76 @@
+package silk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// levelsNative 每帧输出 levels 中对应的采样值, 帧序号超出时重复最后一个
+func levelsNative(levels ...int16) *fakeNative {
+	f := newFakeNative()
+	f.decodeFn = func(call int, in, out []byte, rate int) (int, error) {
+		v := levels[len(levels)-1]
+		if call <= len(levels) {
+			v = levels[call-1]
+		}
+		n := rate * FRAME_LENGTH_MS / 1000 * 2
+		for i := 0; i < n; i += 2 {
+			binary.LittleEndian.PutUint16(out[i:], uint16(v))
+		}
+		return n, nil
+	}
+	return f
+}
+
+func TestDecodeFloat32(t *testing.T) {
+	f := levelsNative(16384, -32768, 0, -8192)
+	useFake(t, f)
+	stream := withFooter(buildStream(nil, payloads(4, 30)...))
+	samples, rate, err := DecodeFloat32(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rate != 16000 {
+		t.Fatalf("rate = %d, want 16000", rate)
+	}
+	if len(samples) != 4*320 {
+		t.Fatalf("%d samples, want %d", len(samples), 4*320)
+	}
+	for i, want := range []float32{0.5, -1, 0, -0.25} {
+		for _, v := range samples[i*320 : (i+1)*320] {
+			if v != want {
+				t.Fatalf("frame %d: sample %v, want %v", i, v, want)
+			}
+		}
+	}
+	f.checkLeaks(t)
+}
+
+func TestDecodeInt32(t *testing.T) {
+	levels := []int16{32767, -32768, 1234}
+	useFake(t, levelsNative(levels...))
+	stream := withFooter(buildStream(nil, payloads(3, 30)...))
+	opts := DecodeOptions{SampleRate: 24000}
+	samples, rate, err := DecodeInt32(bytes.NewReader(stream), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pcm, err := fakeDecoder(levelsNative(levels...)).DecodeWithOptions(bytes.NewReader(stream), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := bytesToSamples(pcm)
+	if rate != 24000 || len(samples) != len(want) || len(want) != 3*480 {
+		t.Fatalf("rate %d, %d samples, int16 decode has %d", rate, len(samples), len(want))
+	}
+	for i, v := range want {
+		if samples[i] != int32(v) {
+			t.Fatalf("sample %d = %d, want %d", i, samples[i], v)
+		}
+	}
+	// 相加时不会在 16bit 处溢出
+	if sum := samples[0] + samples[0]; sum != 65534 {
+		t.Fatalf("sum of full-scale samples = %d", sum)
+	}
+}
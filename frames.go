@@ -0,0 +1,254 @@
+package silk
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// FrameReader 逐个读取 silk 流中 block 的原始负载, 不解码
+// 长度前缀按标准的小端序解析
+type FrameReader struct {
+	r           *bufio.Reader
+	buf         []byte
+	footer      bool
+	declared    time.Duration
+	hasDeclared bool
+}
+
+// NewFrameReader 检查文件头(会丢弃开头的 STX)及其后的扩展块并返回 FrameReader
+func NewFrameReader(src io.Reader) (*FrameReader, error) {
+	r := bufio.NewReader(src)
+	if err := checkHeader(r, 0, logger); err != nil {
+		return nil, err
+	}
+	declared, ok, err := readDeclaredDuration(r)
+	if err != nil {
+		return nil, err
+	}
+	f := &FrameReader{r: r, declared: declared, hasDeclared: ok}
+	lengths, ok, err := readLengthTable(r)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		f.r = bufio.NewReader(&tableReader{src: r, lengths: lengths})
+	}
+	return f, nil
+}
+
+// DeclaredDuration 返回 TDUR 扩展块声明的时长, 没有扩展块时 ok 为 false
+func (f *FrameReader) DeclaredDuration() (d time.Duration, ok bool) {
+	return f.declared, f.hasDeclared
+}
+
+// Next 返回下一个 block 的负载(可能为空), 在下次调用 Next 前有效
+// 遇到 footer 或在 block 边界处 EOF 时返回 io.EOF
+func (f *FrameReader) Next() ([]byte, error) {
+	if f.footer {
+		return nil, io.EOF
+	}
+	var nByte int16
+	if err := binary.Read(f.r, binary.LittleEndian, &nByte); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, io.EOF
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			// 长度前缀只剩 1 字节
+			return nil, fmt.Errorf("%w: 1 trailing byte where a block size was expected", ErrTruncatedStream)
+		}
+		return nil, fmt.Errorf("failed to read block size: %w", err)
+	}
+	if nByte < 0 {
+		f.footer = true
+		return nil, io.EOF
+	}
+	if cap(f.buf) < int(nByte) {
+		f.buf = make([]byte, nByte)
+	}
+	f.buf = f.buf[:nByte]
+	if n, err := io.ReadFull(f.r, f.buf); err != nil {
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil, fmt.Errorf("%w: block declares %d bytes, got %d", ErrTruncatedStream, nByte, n)
+		}
+		return nil, fmt.Errorf("failed to read block: %w", err)
+	}
+	return f.buf, nil
+}
+
+// Footer 返回流是否以 footer 结束, 在 Next 返回 io.EOF 之后有效
+func (f *FrameReader) Footer() bool {
+	return f.footer
+}
+
+// CopyFrames 不解码地将 src 中的 block 原样写入 dst, 返回写入的字节数
+// 输出使用规范的文件头(去掉开头的 STX), 保留所有 block(包括空 block), 源数据有 footer 时同样写出
+func CopyFrames(dst io.Writer, src io.Reader) (int64, error) {
+	fr, err := NewFrameReader(src)
+	if err != nil {
+		return 0, err
+	}
+	var written int64
+	write := func(b []byte) error {
+		n, err := dst.Write(b)
+		written += int64(n)
+		return err
+	}
+	if err = write([]byte(Header)); err != nil {
+		return written, err
+	}
+	var prefix [2]byte
+	for {
+		frame, err := fr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return written, err
+		}
+		binary.LittleEndian.PutUint16(prefix[:], uint16(len(frame)))
+		if err = write(prefix[:]); err != nil {
+			return written, err
+		}
+		if err = write(frame); err != nil {
+			return written, err
+		}
+	}
+	if fr.Footer() {
+		binary.LittleEndian.PutUint16(prefix[:], 0xFFFF) // -1
+		if err = write(prefix[:]); err != nil {
+			return written, err
+		}
+	}
+	return written, nil
+}
+
+// DecodeFrames 按 opts 解码, 每帧(20ms)的 pcm 单独返回, 依次拼接等于 DecodeStream 的输出
+// DTX 静音帧和 PadToSeconds 补齐的静音同样各占一项; 与 DecodeStream 一样不支持 Speed/ResampleTo 等整段选项, 设置时返回 ErrWholeStreamOption
+func DecodeFrames(src io.Reader, opts DecodeOptions) ([][]byte, error) {
+	w := &frameCollector{}
+	if err := newDecoder().DecodeStream(w, src, opts); err != nil {
+		return nil, err
+	}
+	return w.frames, nil
+}
+
+// frameCollector 保存每次 Write 的数据, 解码循环每帧只调用一次 Write
+type frameCollector struct {
+	frames [][]byte
+}
+
+func (w *frameCollector) Write(p []byte) (int, error) {
+	w.frames = append(w.frames, append([]byte(nil), p...))
+	return len(p), nil
+}
+
+// DecodeLengthTable 解码把各帧长度单独存放(长度表)而不是内联在每帧之前的容器格式
+// payloads 为按顺序拼接的各帧负载, 不含文件头; lengths 为对应的长度表, 由调用方从容器中解析.
+// 长度表与负载的字节数不一致(负载不足或有多余的数据)时返回错误.
+// 文件头之后带 TLEN 长度表(见 headerext.go)的文件由 Decode 自动识别, 不需要使用这个函数
+func DecodeLengthTable(payloads io.Reader, lengths []int, opts DecodeOptions) ([]byte, error) {
+	for i, n := range lengths {
+		if n < 0 || n > maxBlockBytes {
+			return nil, fmt.Errorf("invalid frame length %d at table index %d", n, i)
+		}
+	}
+	src := io.MultiReader(strings.NewReader(Header), &tableReader{src: payloads, lengths: lengths, exact: true})
+	opts.LengthByteOrder = binary.LittleEndian // 长度前缀由 tableReader 生成
+	return newDecoder().DecodeWithOptions(src, opts)
+}
+
+// tableReader 按长度表在每帧负载之前加上小端序的长度前缀, 转换为内联长度的 block 序列
+type tableReader struct {
+	src     io.Reader
+	lengths []int
+	prefix  []byte // 当前帧尚未读出的长度前缀
+	remain  int    // 当前帧尚未读出的负载字节数
+	exact   bool   // 长度表用完后 src 仍有数据时报错, 而不是忽略
+}
+
+func (t *tableReader) Read(p []byte) (int, error) {
+	for len(t.prefix) == 0 && t.remain == 0 {
+		if len(t.lengths) == 0 {
+			if t.exact {
+				var b [1]byte
+				if n, err := io.ReadFull(t.src, b[:]); n > 0 {
+					return 0, errors.New("payloads have data beyond the length table")
+				} else if !errors.Is(err, io.EOF) {
+					return 0, err
+				}
+			}
+			return 0, io.EOF
+		}
+		t.prefix = make([]byte, 2)
+		binary.LittleEndian.PutUint16(t.prefix, uint16(t.lengths[0]))
+		t.remain = t.lengths[0]
+		t.lengths = t.lengths[1:]
+	}
+	if len(t.prefix) > 0 {
+		n := copy(p, t.prefix)
+		t.prefix = t.prefix[n:]
+		return n, nil
+	}
+	if len(p) > t.remain {
+		p = p[:t.remain]
+	}
+	n, err := t.src.Read(p)
+	t.remain -= n
+	if errors.Is(err, io.EOF) {
+		if t.remain > 0 {
+			return n, io.ErrUnexpectedEOF
+		}
+		err = nil
+	}
+	return n, err
+}
+
+// DecodeFromFunc 以 rate 采样率解码由 next 逐帧提供的负载, 用于帧来自 RTP 解包、消息队列等自定义传输的情况
+// next 返回下一帧的负载(不含长度前缀), done 为 true 表示没有更多的帧(此时返回的负载被忽略), 返回的错误会中止解码.
+// 空负载按 DTX 帧处理(包括第一帧之前的), 计入 DTX 帧数并输出一帧静音, 保持与发送端的时间轴对齐
+func DecodeFromFunc(next func() ([]byte, bool, error), rate int) ([]byte, error) {
+	src := io.MultiReader(strings.NewReader(Header), &funcReader{next: next})
+	// 长度前缀由 funcReader 生成, 不需要自动判断字节序, 开头也不会有填充
+	opts := DecodeOptions{SampleRate: rate, LengthByteOrder: binary.LittleEndian, EmitDtxSilence: true}
+	opts.leadingDtx = true
+	return newDecoder().DecodeWithOptions(src, opts)
+}
+
+// funcReader 把 next 提供的每帧负载加上小端序的长度前缀, 转换为内联长度的 block 序列
+type funcReader struct {
+	next  func() ([]byte, bool, error)
+	frame int    // 已取得的帧数
+	buf   []byte // 当前帧尚未读出的前缀和负载
+	done  bool
+}
+
+func (f *funcReader) Read(p []byte) (int, error) {
+	for len(f.buf) == 0 {
+		if f.done {
+			return 0, io.EOF
+		}
+		payload, done, err := f.next()
+		if err != nil {
+			return 0, fmt.Errorf("frame supplier failed at frame %d: %w", f.frame, err)
+		}
+		if done {
+			f.done = true
+			continue
+		}
+		if len(payload) > maxBlockBytes {
+			return 0, fmt.Errorf("invalid frame length %d at frame %d", len(payload), f.frame)
+		}
+		f.frame++
+		f.buf = make([]byte, 2+len(payload))
+		binary.LittleEndian.PutUint16(f.buf, uint16(len(payload)))
+		copy(f.buf[2:], payload)
+	}
+	n := copy(p, f.buf)
+	f.buf = f.buf[n:]
+	return n, nil
+}
@@ -0,0 +1,199 @@
+package silk
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestCopyFramesRoundTrip(t *testing.T) {
+	frames := payloads(4, 25)
+	for _, stream := range [][]byte{
+		withFooter(buildStream(nil, frames...)),
+		buildStream(nil, frames...),                             // 没有 footer
+		withFooter(buildStream(nil, frames[0], nil, frames[1])), // 保留空 block
+	} {
+		var out bytes.Buffer
+		n, err := CopyFrames(&out, bytes.NewReader(stream))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != int64(out.Len()) || !bytes.Equal(out.Bytes(), stream) {
+			t.Errorf("CopyFrames wrote %d bytes\n%q\nwant\n%q", n, out.Bytes(), stream)
+		}
+	}
+}
+
+func TestCopyFramesNormalizesHeader(t *testing.T) {
+	frames := payloads(3, 25)
+	for _, tc := range []struct {
+		name       string
+		stream     []byte
+		normalized []byte
+	}{
+		{"STX", append([]byte{STX}, withFooter(buildStream(nil, frames...))...), withFooter(buildStream(nil, frames...))},
+		// 长度表改写为内联的长度前缀, 长度表格式没有 footer
+		{"length table", buildStream(lengthTable(frames...)), buildStream(nil, frames...)},
+	} {
+		var out bytes.Buffer
+		if _, err := CopyFrames(&out, bytes.NewReader(tc.stream)); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if !bytes.Equal(out.Bytes(), tc.normalized) {
+			t.Errorf("%s: got\n%q\nwant\n%q", tc.name, out.Bytes(), tc.normalized)
+		}
+	}
+}
+
+func TestCopyFramesTruncated(t *testing.T) {
+	stream := buildStream(nil, payloads(3, 25)...)
+	var out bytes.Buffer
+	if _, err := CopyFrames(&out, bytes.NewReader(stream[:len(stream)-5])); !errors.Is(err, ErrTruncatedStream) {
+		t.Fatalf("err = %v, want ErrTruncatedStream", err)
+	}
+}
+
+func TestDecodeFramesMatchesDecode(t *testing.T) {
+	f := newFakeNative()
+	useFake(t, f)
+	frames := payloads(5, 30)
+	stream := withFooter(buildStream(nil, frames[0], frames[1], nil, frames[2], frames[3], frames[4]))
+	opts := DecodeOptions{EmitDtxSilence: true, PadToSeconds: true}
+	got, err := DecodeFrames(bytes.NewReader(stream), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := fakeDecoder(f).DecodeWithOptions(bytes.NewReader(stream), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if joined := bytes.Join(got, nil); !bytes.Equal(joined, want) {
+		t.Fatalf("joined frames have %d bytes, Decode returned %d", len(joined), len(want))
+	}
+	// 5 帧 + 1 个 DTX 静音帧 + 补齐到整秒
+	if len(got) != 7 {
+		t.Fatalf("got %d frames, want 7", len(got))
+	}
+	for i, frame := range got[:6] {
+		if len(frame) != 640 {
+			t.Errorf("frame %d has %d bytes, want 640", i, len(frame))
+		}
+	}
+	f.checkLeaks(t)
+}
+
+func TestDecodeFramesRejectsWholeStreamOptions(t *testing.T) {
+	useFake(t, newFakeNative())
+	stream := withFooter(buildStream(nil, payloads(2, 30)...))
+	if _, err := DecodeFrames(bytes.NewReader(stream), DecodeOptions{ResampleTo: 8000}); !errors.Is(err, ErrWholeStreamOption) {
+		t.Fatalf("err = %v, want ErrWholeStreamOption", err)
+	}
+}
+
+// sliceSupplier 依次返回 frames 中的负载, 用于 DecodeFromFunc
+func sliceSupplier(frames [][]byte) func() ([]byte, bool, error) {
+	i := 0
+	return func() ([]byte, bool, error) {
+		if i == len(frames) {
+			return nil, true, nil
+		}
+		i++
+		return frames[i-1], false, nil
+	}
+}
+
+func TestDecodeFromFunc(t *testing.T) {
+	f := newFakeNative()
+	useFake(t, f)
+	pcm, err := DecodeFromFunc(sliceSupplier(payloads(3, 30)), 16000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.checkLeaks(t)
+	if got := firstSamples(pcm, 640); len(pcm) != 3*640 || !equalSamples(got, wantFrames(3)) {
+		t.Fatalf("%d bytes, frames %v, want %v", len(pcm), got, wantFrames(3))
+	}
+}
+
+func TestDecodeFromFuncEmptyPayloads(t *testing.T) {
+	f := newFakeNative()
+	useFake(t, f)
+	// 开头的空负载超过解码器对填充的上限, 仍然按 DTX 帧输出静音
+	lead := maxLeadingZeroBlocks + 8
+	frames := make([][]byte, lead)
+	frames = append(frames, payloads(2, 30)...)
+	frames = append(frames, nil, payloads(3, 30)[2])
+	pcm, err := DecodeFromFunc(sliceSupplier(frames), 16000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.checkLeaks(t)
+	w := wantFrames(3)
+	want := append(make([]int16, lead), w[0], w[1], 0, w[2])
+	if got := firstSamples(pcm, 640); len(pcm) != len(want)*640 || !equalSamples(got, want) {
+		t.Fatalf("%d bytes, frames %v, want %v", len(pcm), got, want)
+	}
+}
+
+func TestDecodeFromFuncErrors(t *testing.T) {
+	useFake(t, newFakeNative())
+	errSupplier := errors.New("queue closed")
+	calls := 0
+	failing := func() ([]byte, bool, error) {
+		if calls++; calls == 3 {
+			return nil, false, errSupplier
+		}
+		return []byte("abcd"), false, nil
+	}
+	if _, err := DecodeFromFunc(failing, 16000); !errors.Is(err, errSupplier) {
+		t.Fatalf("supplier error: err = %v, want %v", err, errSupplier)
+	}
+	big := [][]byte{make([]byte, maxBlockBytes+1)}
+	if _, err := DecodeFromFunc(sliceSupplier(big), 16000); err == nil {
+		t.Fatal("accepted a payload longer than a block")
+	}
+}
+
+func TestDecodeLengthTable(t *testing.T) {
+	f := newFakeNative()
+	useFake(t, f)
+	frames := [][]byte{bytes.Repeat([]byte("a"), 30), bytes.Repeat([]byte("b"), 7), bytes.Repeat([]byte("c"), 12)}
+	lengths := []int{30, 7, 12}
+	got, err := DecodeLengthTable(bytes.NewReader(bytes.Join(frames, nil)), lengths, DecodeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := fakeDecoder(f).Decode(bytes.NewReader(buildStream(nil, frames...)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Fatalf("length table decoded %d bytes, inline lengths %d", len(got), len(want))
+	}
+	f.checkLeaks(t)
+}
+
+func TestDecodeLengthTableMismatch(t *testing.T) {
+	f := newFakeNative()
+	useFake(t, f)
+	data := bytes.Repeat([]byte("a"), 40)
+	// 长度表比负载长
+	if _, err := DecodeLengthTable(bytes.NewReader(data), []int{30, 20}, DecodeOptions{}); !errors.Is(err, ErrTruncatedStream) {
+		t.Errorf("table longer than payloads: err = %v, want ErrTruncatedStream", err)
+	}
+	// 负载比长度表长
+	if _, err := DecodeLengthTable(bytes.NewReader(data), []int{30, 5}, DecodeOptions{}); err == nil {
+		t.Error("payloads longer than table: decode succeeded")
+	}
+	f.checkLeaks(t)
+
+	created := f.created
+	for _, lengths := range [][]int{{30, -1}, {maxBlockBytes + 1}} {
+		if _, err := DecodeLengthTable(bytes.NewReader(data), lengths, DecodeOptions{}); err == nil {
+			t.Errorf("lengths %v: decode succeeded", lengths)
+		}
+	}
+	if f.created != created {
+		t.Errorf("invalid tables created %d decoders", f.created-created)
+	}
+}
@@ -0,0 +1,20 @@
+package silk
+
+import (
+	"fmt"
+	"io/fs"
+)
+
+// DecodeFS 从 fsys(如 embed.FS、zip)中打开 name 并按 opts 解码, 返回 pcm 数据
+func DecodeFS(fsys fs.FS, name string, opts DecodeOptions) ([]byte, error) {
+	src, err := fsys.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	pcm, err := newDecoder().DecodeWithOptions(src, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", name, err)
+	}
+	return pcm, nil
+}
@@ -0,0 +1,31 @@
+package silk
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDecodeFS(t *testing.T) {
+	f := newFakeNative()
+	useFake(t, f)
+	fsys := fstest.MapFS{
+		"voice/a.silk":   {Data: withFooter(buildStream(nil, payloads(3, 30)...))},
+		"voice/bad.silk": {Data: []byte("not a silk file")},
+	}
+	pcm, err := DecodeFS(fsys, "voice/a.silk", DecodeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pcm) != 3*640 {
+		t.Fatalf("decoded %d bytes, want %d", len(pcm), 3*640)
+	}
+	if _, err = DecodeFS(fsys, "voice/missing.silk", DecodeOptions{}); !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("missing file: err = %v, want fs.ErrNotExist", err)
+	}
+	if _, err = DecodeFS(fsys, "voice/bad.silk", DecodeOptions{}); !errors.Is(err, ErrInvalidHeader) {
+		t.Fatalf("bad file: err = %v, want ErrInvalidHeader", err)
+	}
+	f.checkLeaks(t)
+}
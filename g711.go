@@ -0,0 +1,89 @@
+package silk
+
+// Companding 输出 pcm 的压扩编码
+type Companding int
+
+const (
+	// CompandingNone 16bit 线性 pcm
+	CompandingNone Companding = iota
+	// CompandingULaw G.711 μ-law, 每个采样 1 字节
+	CompandingULaw
+	// CompandingALaw G.711 A-law, 每个采样 1 字节
+	CompandingALaw
+)
+
+// compand 将 16bit pcm 转换为 c 对应的 8bit 编码
+func compand(pcm []byte, c Companding) []byte {
+	var encode func(int16) byte
+	switch c {
+	case CompandingULaw:
+		encode = linearToULaw
+	case CompandingALaw:
+		encode = linearToALaw
+	default:
+		return pcm
+	}
+	samples := bytesToSamples(pcm)
+	out := make([]byte, len(samples))
+	for i, v := range samples {
+		out[i] = encode(v)
+	}
+	return out
+}
+
+// G.711 各段的上限, μ-law 按加偏置后的 16bit 幅度, A-law 按 13bit 幅度
+var (
+	uLawSegmentEnd = [8]int{0xFF, 0x1FF, 0x3FF, 0x7FF, 0xFFF, 0x1FFF, 0x3FFF, 0x7FFF}
+	aLawSegmentEnd = [8]int{0x1F, 0x3F, 0x7F, 0xFF, 0x1FF, 0x3FF, 0x7FF, 0xFFF}
+)
+
+// segmentOf 返回 v 在 segmentEnd 中所在的段, 超出最后一段时返回 8
+func segmentOf(v int, segmentEnd *[8]int) int {
+	for i, end := range segmentEnd {
+		if v <= end {
+			return i
+		}
+	}
+	return 8
+}
+
+// linearToULaw 按 ITU-T G.711 将 16bit 采样编码为 μ-law
+func linearToULaw(sample int16) byte {
+	const bias, clip = 0x84, 32635
+	v := int(sample)
+	mask := byte(0xFF)
+	if v < 0 {
+		v = -v
+		mask = 0x7F
+	}
+	if v > clip {
+		v = clip
+	}
+	v += bias
+	seg := segmentOf(v, &uLawSegmentEnd)
+	if seg >= 8 {
+		return 0x7F ^ mask
+	}
+	return (byte(seg<<4) | byte((v>>(seg+3))&0x0F)) ^ mask
+}
+
+// linearToALaw 按 ITU-T G.711 将 16bit 采样编码为 A-law
+func linearToALaw(sample int16) byte {
+	v := int(sample) >> 3 // A-law 使用 13bit 幅度
+	mask := byte(0xD5)
+	if v < 0 {
+		v = -v - 1
+		mask = 0x55
+	}
+	seg := segmentOf(v, &aLawSegmentEnd)
+	if seg >= 8 {
+		return 0x7F ^ mask
+	}
+	code := byte(seg << 4)
+	if seg < 2 {
+		code |= byte((v >> 1) & 0x0F)
+	} else {
+		code |= byte((v >> seg) & 0x0F)
+	}
+	return code ^ mask
+}
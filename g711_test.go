@@ -0,0 +1,177 @@
+package silk
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+)
+
+// uLawToLinear 按 G.711 参考实现把 μ-law 解码为 16bit 采样, 取量化区间的中点
+func uLawToLinear(u byte) int {
+	u = ^u
+	seg := uint(u>>4) & 0x07
+	v := (int(u&0x0F)<<3 + 0x84) << seg
+	if u&0x80 != 0 {
+		return 0x84 - v
+	}
+	return v - 0x84
+}
+
+// aLawToLinear 按 G.711 参考实现把 A-law 解码为 16bit 采样, 取量化区间的中点
+func aLawToLinear(a byte) int {
+	a ^= 0x55
+	seg := uint(a>>4) & 0x07
+	v := int(a&0x0F) << 4
+	switch seg {
+	case 0:
+		v += 8
+	default:
+		v = (v + 0x108) << (seg - 1)
+	}
+	if a&0x80 != 0 {
+		return v
+	}
+	return -v
+}
+
+func absInt(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
+
+func TestULawRoundTrip(t *testing.T) {
+	for x := -32768; x <= 32767; x++ {
+		code := linearToULaw(int16(x))
+		y := uLawToLinear(code)
+		// 量化步长为 8<<seg, 还原取中点, 误差不超过半个步长; 超过 clip 的部分被削顶
+		seg := uint(^code>>4) & 0x07
+		bound := 4 << seg
+		if over := absInt(x) - 32635; over > 0 {
+			bound += over
+		}
+		if err := absInt(x - y); err > bound {
+			t.Fatalf("μ-law %d -> %#02x -> %d, error %d > %d", x, code, y, err, bound)
+		}
+		if x != 0 && y != 0 && (x < 0) != (y < 0) {
+			t.Fatalf("μ-law %d -> %d changed sign", x, y)
+		}
+	}
+}
+
+func TestALawRoundTrip(t *testing.T) {
+	for x := -32768; x <= 32767; x++ {
+		code := linearToALaw(int16(x))
+		y := aLawToLinear(code)
+		// 13bit 量化步长为 16<<(seg-1) (前两段为 16), 加上右移 3 位丢弃的低位
+		seg := uint(code^0x55) >> 4 & 0x07
+		step := 16
+		if seg > 1 {
+			step <<= seg - 1
+		}
+		bound := step/2 + 8
+		if err := absInt(x - y); err > bound {
+			t.Fatalf("A-law %d -> %#02x -> %d, error %d > %d", x, code, y, err, bound)
+		}
+	}
+}
+
+func TestG711KnownCodes(t *testing.T) {
+	tests := []struct {
+		sample      int16
+		ulaw, alaw  byte
+		description string
+	}{
+		{0, 0xFF, 0xD5, "silence"},
+		{32767, 0x80, 0xAA, "positive full scale"},
+		{-32768, 0x00, 0x2A, "negative full scale"},
+	}
+	for _, tt := range tests {
+		if got := linearToULaw(tt.sample); got != tt.ulaw {
+			t.Errorf("%s: linearToULaw(%d) = %#02x, want %#02x", tt.description, tt.sample, got, tt.ulaw)
+		}
+		if got := linearToALaw(tt.sample); got != tt.alaw {
+			t.Errorf("%s: linearToALaw(%d) = %#02x, want %#02x", tt.description, tt.sample, got, tt.alaw)
+		}
+	}
+}
+
+func TestCompand(t *testing.T) {
+	pcm := samplesToBytes([]int16{0, 1000, -1000, 32767, -32768})
+	if got := compand(pcm, CompandingNone); !bytes.Equal(got, pcm) {
+		t.Fatal("CompandingNone changed the pcm")
+	}
+	for _, c := range []Companding{CompandingULaw, CompandingALaw} {
+		got := compand(pcm, c)
+		if len(got) != len(pcm)/2 {
+			t.Fatalf("companding %d: %d bytes for %d samples", c, len(got), len(pcm)/2)
+		}
+	}
+}
+
+func TestDecodeCompanding(t *testing.T) {
+	stream := buildStream(nil, payloads(3, 4)...)
+	linear, _, err := decodeFake(t, stream, DecodeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	samples := bytesToSamples(linear)
+	for _, tt := range []struct {
+		c      Companding
+		encode func(int16) byte
+	}{
+		{CompandingULaw, linearToULaw},
+		{CompandingALaw, linearToALaw},
+	} {
+		got, _, err := decodeFake(t, stream, DecodeOptions{Companding: tt.c})
+		if err != nil {
+			t.Fatalf("companding %d: %v", tt.c, err)
+		}
+		if len(got) != len(samples) {
+			t.Fatalf("companding %d: %d bytes, want one per sample (%d)", tt.c, len(got), len(samples))
+		}
+		for i, v := range samples {
+			if got[i] != tt.encode(v) {
+				t.Fatalf("companding %d: byte %d = %#02x, want %#02x", tt.c, i, got[i], tt.encode(v))
+			}
+		}
+	}
+}
+
+func TestCompandedOutputRejected(t *testing.T) {
+	f := newFakeNative()
+	useFake(t, f)
+	opts := DecodeOptions{Companding: CompandingULaw}
+	stream := buildStream(nil, payloads(2, 4)...)
+	checks := map[string]func(io.Reader) error{
+		"DecodeWaveform": func(r io.Reader) error {
+			_, err := DecodeWaveform(r, 10, opts)
+			return err
+		},
+		"DecodeInt32": func(r io.Reader) error {
+			_, _, err := DecodeInt32(r, opts)
+			return err
+		},
+		"DecodePlaylist": func(r io.Reader) error {
+			_, err := DecodePlaylist([]io.Reader{r}, opts)
+			return err
+		},
+		"DecodeClip": func(r io.Reader) error {
+			_, err := DecodeClip(r, opts)
+			return err
+		},
+	}
+	for name, check := range checks {
+		if err := check(bytes.NewReader(stream)); !errors.Is(err, ErrCompandedOutput) {
+			t.Errorf("%s: err = %v, want ErrCompandedOutput", name, err)
+		}
+	}
+	if f.created != 0 {
+		t.Errorf("rejected calls created %d decoders", f.created)
+	}
+	if err := fakeDecoder(f).DecodeStream(io.Discard, bytes.NewReader(stream), opts); !errors.Is(err, ErrWholeStreamOption) {
+		t.Errorf("DecodeStream: err = %v, want ErrWholeStreamOption", err)
+	}
+}
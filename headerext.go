@@ -0,0 +1,83 @@
+package silk
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// #!SILK_V3 之后可选的扩展块, 没有公开的规范, 包内识别的布局如下:
+//
+//	"TDUR" + uint32(小端序)  声明的总时长, 单位毫秒
+//	"TLEN" + uint32(小端序)帧数 N + N 个 uint16(小端序)帧长度  长度表, 之后是按顺序拼接的各帧负载, 没有内联的长度前缀
+//
+// 两者都出现时 TDUR 在前, 长度表之后不再识别其他扩展块.
+// 标记的前两个字节按小端序解释为 block 长度时(0x4454, 0x4c54)远大于 maxBlockBytes, 不会与正常的第一个 block 混淆,
+// 因此只要文件头之后紧跟标记就视为扩展块, 否则按标准的内联长度前缀解析
+const (
+	durationMarker    = "TDUR"
+	lengthTableMarker = "TLEN"
+	maxTableFrames    = 1 << 20 // 长度表最多的帧数(约 5.8 小时), 防止构造的数据分配过多内存
+)
+
+// durationExtLen TDUR 扩展块的长度
+const durationExtLen = len(durationMarker) + 4
+
+// readDeclaredDuration 读取文件头之后的 TDUR 扩展块, 没有时 ok 为 false 且不消费任何数据
+func readDeclaredDuration(r *bufio.Reader) (d time.Duration, ok bool, err error) {
+	if marker, _ := r.Peek(len(durationMarker)); string(marker) != durationMarker {
+		return 0, false, nil
+	}
+	var ext [durationExtLen]byte
+	if n, err := io.ReadFull(r, ext[:]); err != nil {
+		return 0, false, fmt.Errorf("%w: duration extension has %d of %d bytes", ErrTruncatedHeader, n, durationExtLen)
+	}
+	ms := binary.LittleEndian.Uint32(ext[len(durationMarker):])
+	return time.Duration(ms) * time.Millisecond, true, nil
+}
+
+// readLengthTable 读取文件头之后的 TLEN 长度表, 没有时 ok 为 false 且不消费任何数据
+func readLengthTable(r *bufio.Reader) (lengths []int, ok bool, err error) {
+	if marker, _ := r.Peek(len(lengthTableMarker)); string(marker) != lengthTableMarker {
+		return nil, false, nil
+	}
+	var head [8]byte
+	if n, err := io.ReadFull(r, head[:]); err != nil {
+		return nil, false, fmt.Errorf("%w: length table header has %d of %d bytes", ErrTruncatedHeader, n, len(head))
+	}
+	count := binary.LittleEndian.Uint32(head[len(lengthTableMarker):])
+	if count > maxTableFrames {
+		return nil, false, fmt.Errorf("invalid length table: %d frames, limit %d", count, maxTableFrames)
+	}
+	table := make([]byte, 2*count)
+	if n, err := io.ReadFull(r, table); err != nil {
+		return nil, false, fmt.Errorf("%w: length table has %d of %d bytes", ErrTruncatedHeader, n, len(table))
+	}
+	lengths = make([]int, count)
+	for i := range lengths {
+		lengths[i] = int(binary.LittleEndian.Uint16(table[2*i:]))
+		if lengths[i] > maxBlockBytes {
+			return nil, false, fmt.Errorf("invalid frame length %d at table index %d", lengths[i], i)
+		}
+	}
+	return lengths, true, nil
+}
+
+// readHeaderExt 依次读取文件头之后的扩展块, 有长度表时返回按表加上内联长度前缀的 reader, 否则返回 r
+func readHeaderExt(r *bufio.Reader, size int) (blocks *bufio.Reader, ext []string, err error) {
+	if _, ok, err := readDeclaredDuration(r); err != nil {
+		return nil, nil, err
+	} else if ok {
+		ext = append(ext, durationMarker)
+	}
+	lengths, ok, err := readLengthTable(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		return r, ext, nil
+	}
+	return bufio.NewReaderSize(&tableReader{src: r, lengths: lengths}, size), append(ext, lengthTableMarker), nil
+}
@@ -0,0 +1,79 @@
+package silk
+
+import (
+	"errors"
+	"io"
+	"net/http"
+)
+
+// ServeWav 将 src 边解码边以 wav 写入 w, 用于直接响应语音转换请求
+//
+// 解码出第一帧之前出错时可以返回状态码: src 不是有效的 silk 返回 400, 其他错误返回 500;
+// 开始输出后长度未知, RIFF/data 长度写为最大值(chunked 传输), 之后出错只能中断输出.
+// r.Context() 取消时在帧之间停止解码.
+func ServeWav(w http.ResponseWriter, r *http.Request, src io.Reader) {
+	opts := DecodeOptions{}.withDefaults()
+	sw := &wavResponseWriter{w: w, sampleRate: opts.SampleRate}
+	opts.rateDetected = func(rate int) { sw.sampleRate = rate }
+	err := newDecoder().DecodeStreamContext(r.Context(), sw, src, opts)
+	switch {
+	case err == nil:
+		if !sw.started {
+			// 没有音频, 长度是确定的
+			if err = sw.start(0); err != nil {
+				logger.Warn("failed to write wav header: %+v", err)
+			}
+		}
+	case sw.started:
+		logger.Warn("failed to serve wav after response started: %+v", err)
+	case r.Context().Err() != nil:
+		// 客户端已断开, 不需要再响应
+	case isInvalidInput(err):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// isInvalidInput 判断 err 是否由 src 内容无效导致
+func isInvalidInput(err error) bool {
+	return errors.Is(err, ErrInvalidHeader) ||
+		errors.Is(err, ErrAlreadyWav) ||
+		errors.Is(err, ErrUnsupportedAMRWB) ||
+		errors.Is(err, ErrTruncatedHeader) ||
+		errors.Is(err, ErrTruncatedStream) ||
+		errors.Is(err, ErrMaxFramesExceeded) ||
+		errors.Is(err, ErrNoAudio) ||
+		errors.Is(err, io.EOF) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+// wavResponseWriter 在写入第一帧 pcm 时才发送响应头和 wav 文件头
+type wavResponseWriter struct {
+	w          http.ResponseWriter
+	sampleRate int
+	started    bool
+}
+
+// start 发送响应头和 wav 文件头, dataLen < 0 表示长度未知
+func (sw *wavResponseWriter) start(dataLen int) error {
+	sw.started = true
+	sw.w.Header().Set("Content-Type", "audio/wav")
+	sw.w.WriteHeader(http.StatusOK)
+	// 长度未知时 RIFF/data 长度写为最大值, 浏览器等播放器会一直读到流结束
+	opts := WavOptions{SampleRate: sw.sampleRate, UnknownLength: dataLen < 0}
+	if dataLen < 0 {
+		dataLen = 0
+	}
+	_, err := WriteWavHeader(sw.w, dataLen, opts)
+	return err
+}
+
+func (sw *wavResponseWriter) Write(p []byte) (int, error) {
+	if !sw.started {
+		if err := sw.start(-1); err != nil {
+			return 0, err
+		}
+	}
+	return sw.w.Write(p)
+}
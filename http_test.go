@@ -0,0 +1,87 @@
+package silk
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// serveWav 用 fakeNative 调用 ServeWav, 返回响应
+func serveWav(t *testing.T, f *fakeNative, src []byte) *httptest.ResponseRecorder {
+	t.Helper()
+	useFake(t, f)
+	w := httptest.NewRecorder()
+	ServeWav(w, httptest.NewRequest(http.MethodPost, "/wav", nil), bytes.NewReader(src))
+	f.checkLeaks(t)
+	return w
+}
+
+func TestServeWav(t *testing.T) {
+	w := serveWav(t, newFakeNative(), withFooter(buildStream(nil, payloads(3, 30)...)))
+	if w.Code != http.StatusOK || w.Header().Get("Content-Type") != "audio/wav" {
+		t.Fatalf("status %d, Content-Type %q", w.Code, w.Header().Get("Content-Type"))
+	}
+	body := w.Body.Bytes()
+	if len(body) != wavHeaderLen+3*640 || !equalSamples(firstSamples(body[wavHeaderLen:], 640), wantFrames(3)) {
+		t.Fatalf("body %d bytes, frames %v", len(body), firstSamples(body[wavHeaderLen:], 640))
+	}
+}
+
+func TestServeWavBadInput(t *testing.T) {
+	stream := withFooter(buildStream(nil, payloads(3, 30)...))
+	for _, tc := range []struct {
+		name string
+		src  []byte
+	}{
+		{"invalid header", []byte("#!SILK_V2 not silk")},
+		{"truncated header", []byte(Header[:4])},
+		{"AMR-WB", append([]byte(amrWBMagic), 0x3C, 0x48, 0x17, 0x16, 0x80, 0xE0, 0x11, 0x10, 0x00, 0x00)},
+		{"already wav", pcmToWav(make([]byte, 64), 1, 16000)},
+		{"truncated first block", stream[:HeaderLen+10]},
+		{"no audio", withFooter([]byte(Header))},
+	} {
+		w := serveWav(t, newFakeNative(), tc.src)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want 400", tc.name, w.Code)
+		}
+	}
+}
+
+func TestServeWavDecoderFailure(t *testing.T) {
+	stream := withFooter(buildStream(nil, payloads(3, 30)...))
+
+	f := newFakeNative()
+	f.createErr = errors.New("CreateDecoder failed")
+	if w := serveWav(t, f, stream); w.Code != http.StatusInternalServerError {
+		t.Errorf("create failure: status %d, want 500", w.Code)
+	}
+
+	f = newFakeNative()
+	f.decodeFn = func(call int, in, out []byte, rate int) (int, error) {
+		return 0, errors.New("decode failed")
+	}
+	if w := serveWav(t, f, stream); w.Code != http.StatusInternalServerError {
+		t.Errorf("decode failure: status %d, want 500", w.Code)
+	}
+}
+
+func TestServeWavFailureAfterStart(t *testing.T) {
+	f := newFakeNative()
+	f.decodeFn = func(call int, in, out []byte, rate int) (int, error) {
+		if call == 2 {
+			return 0, errors.New("decode failed")
+		}
+		return fakeFrame(in, out, rate), nil
+	}
+	var w *httptest.ResponseRecorder
+	logs := captureLogs(func() { w = serveWav(t, f, withFooter(buildStream(nil, payloads(3, 30)...))) })
+	// 已经发送了 200 和第一帧, 只能中断输出
+	if w.Code != http.StatusOK || w.Body.Len() != wavHeaderLen+640 {
+		t.Fatalf("status %d, body %d bytes, want 200 with the first frame", w.Code, w.Body.Len())
+	}
+	if !logs.contains("after response started") {
+		t.Fatalf("no warning for the failure after start: %v", logs.lines)
+	}
+}
@@ -0,0 +1,57 @@
+package silk
+
+import (
+	"sort"
+	"time"
+)
+
+// DecodeInfo 解码统计信息
+type DecodeInfo struct {
+	SampleRate          int            // 请求 dll 输出的采样率(DecodeOptions.SampleRate)
+	EffectiveSampleRate int            // dll 实际输出的采样率, dll 导出 getSampleRate 时以其结果为准, 否则等于 SampleRate
+	Frames              int            // 输出了 pcm 的帧数
+	Channels            int            // dll 输出的声道数(混音前), 没有解码出 pcm 时为 0
+	Samples             int            // 解码出的采样数(不含 DTX 静音和补齐部分)
+	DtxFrames           int            // 第一帧之后长度为 0 的 block(DTX 静音帧)数, 开头的填充不计入
+	Clipped             int            // 其中达到 ±32767 的采样数, 用于调整 Gain
+	InputHash           uint32         // DecodeOptions.HashInput 开启时为整个输入的 CRC32(IEEE)
+	Truncated           bool           // 达到 DecodeOptions.MaxOutputBytes 后提前停止
+	TrimmedAll          bool           // DecodeOptions.TrimStartMs/TrimEndMs 超过音频长度, 结果为空
+	CompressionRatio    float64        // 解码出的 pcm 字节数(含 DTX 静音, 不含补齐)除以已读取的 silk 字节数, 过低时可能是损坏的文件
+	Profile             *DecodeProfile // DecodeOptions.Profile 开启时为 dll Decode 调用的耗时统计
+}
+
+// ClipPercent 返回削波采样占全部采样的百分比
+func (i DecodeInfo) ClipPercent() float64 {
+	if i.Samples == 0 {
+		return 0
+	}
+	return float64(i.Clipped) * 100 / float64(i.Samples)
+}
+
+// DecodeProfile dll Decode 调用的耗时统计
+type DecodeProfile struct {
+	Calls  int
+	Total  time.Duration
+	Min    time.Duration
+	Median time.Duration
+	P99    time.Duration
+	Max    time.Duration
+}
+
+func newDecodeProfile(durations []time.Duration) *DecodeProfile {
+	p := &DecodeProfile{Calls: len(durations)}
+	if len(durations) == 0 {
+		return p
+	}
+	sorted := append([]time.Duration(nil), durations...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	for _, d := range sorted {
+		p.Total += d
+	}
+	p.Min = sorted[0]
+	p.Max = sorted[len(sorted)-1]
+	p.Median = sorted[len(sorted)/2]
+	p.P99 = sorted[(len(sorted)*99+99)/100-1]
+	return p
+}
@@ -0,0 +1,60 @@
+package silk
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestNewDecodeProfile(t *testing.T) {
+	var durations []time.Duration
+	for i := 100; i >= 1; i-- {
+		durations = append(durations, time.Duration(i)*time.Millisecond)
+	}
+	got := *newDecodeProfile(durations)
+	want := DecodeProfile{
+		Calls:  100,
+		Total:  5050 * time.Millisecond,
+		Min:    time.Millisecond,
+		Median: 51 * time.Millisecond,
+		P99:    99 * time.Millisecond,
+		Max:    100 * time.Millisecond,
+	}
+	if got != want {
+		t.Fatalf("profile %+v, want %+v", got, want)
+	}
+	if p := newDecodeProfile(nil); *p != (DecodeProfile{}) {
+		t.Fatalf("empty profile %+v", *p)
+	}
+}
+
+func TestDecodeProfile(t *testing.T) {
+	f := newFakeNative()
+	f.decodeFn = func(call int, in, out []byte, rate int) (int, error) {
+		if call == 3 {
+			time.Sleep(5 * time.Millisecond)
+		}
+		return fakeFrame(in, out, rate), nil
+	}
+	stream := withFooter(buildStream(nil, payloads(5, 4)...))
+	_, info, err := fakeDecoder(f).DecodeWithInfo(bytes.NewReader(stream), DecodeOptions{Profile: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := info.Profile
+	if p == nil {
+		t.Fatal("Profile is nil")
+	}
+	if p.Calls != 5 || p.Max < 5*time.Millisecond || p.Total < p.Max {
+		t.Fatalf("profile %+v, want 5 calls with the slow one as Max", *p)
+	}
+	if !(p.Min <= p.Median && p.Median <= p.P99 && p.P99 <= p.Max) {
+		t.Fatalf("profile %+v is not ordered", *p)
+	}
+
+	// 未开启时不统计
+	if _, info, err = fakeDecoder(f).DecodeWithInfo(bytes.NewReader(stream), DecodeOptions{}); err != nil || info.Profile != nil {
+		t.Fatalf("without Profile: %+v, err = %v", info.Profile, err)
+	}
+	f.checkLeaks(t)
+}
@@ -0,0 +1,46 @@
+package silk
+
+import (
+	"fmt"
+
+	"github.com/0xrawsec/golang-utils/log"
+)
+
+// Logger 包内使用的日志接口, 可通过 SetLogger 替换
+type Logger interface {
+	Debug(format string, args ...interface{})
+	Info(format string, args ...interface{})
+	Warn(format string, args ...interface{})
+}
+
+var logger Logger = defaultLogger{}
+
+// SetLogger 替换包内使用的日志, 传入 nil 则关闭日志
+// 应在开始解码前调用, 不要与解码并发调用
+func SetLogger(l Logger) {
+	if l == nil {
+		l = nopLogger{}
+	}
+	logger = l
+}
+
+// defaultLogger 输出到 golang-utils/log
+type defaultLogger struct{}
+
+func (defaultLogger) Debug(format string, args ...interface{}) {
+	log.Debug(fmt.Sprintf(format, args...))
+}
+
+func (defaultLogger) Info(format string, args ...interface{}) {
+	log.Info(fmt.Sprintf(format, args...))
+}
+
+func (defaultLogger) Warn(format string, args ...interface{}) {
+	log.Warn(fmt.Sprintf(format, args...))
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(string, ...interface{}) {}
+func (nopLogger) Info(string, ...interface{})  {}
+func (nopLogger) Warn(string, ...interface{})  {}
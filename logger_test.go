@@ -0,0 +1,47 @@
+package silk
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDecodeOptionsLogger(t *testing.T) {
+	bad := []byte("#!SILK_V2\x00\x00")
+	stx := append([]byte{STX}, buildStream(nil, payloads(1, 4)...)...)
+	perCall := &recordLogger{}
+	global := captureLogs(func() {
+		_, _, err := decodeFake(t, bad, DecodeOptions{Logger: perCall})
+		if !errors.Is(err, ErrInvalidHeader) {
+			t.Fatalf("err = %v, want ErrInvalidHeader", err)
+		}
+		if _, _, err = decodeFake(t, stx, DecodeOptions{Logger: perCall}); err != nil {
+			t.Fatal(err)
+		}
+	})
+	// checkHeader 的警告和信息都写入本次调用的 Logger
+	if !perCall.contains("invalid file header") || !perCall.contains("leading byte") {
+		t.Fatalf("per-call logger missed the header logs: %v", perCall.lines)
+	}
+	if len(global.lines) != 0 {
+		t.Fatalf("package logger received %v while a per-call Logger was set", global.lines)
+	}
+
+	// 未设置时仍使用包内日志
+	global = captureLogs(func() { decodeFake(t, bad, DecodeOptions{}) })
+	if !global.contains("invalid file header") {
+		t.Fatalf("package logger missed the header warning: %v", global.lines)
+	}
+}
+
+func TestSetLoggerNil(t *testing.T) {
+	prev := logger
+	defer SetLogger(prev)
+	SetLogger(nil)
+	if _, ok := logger.(nopLogger); !ok {
+		t.Fatalf("SetLogger(nil) installed %T, want nopLogger", logger)
+	}
+	// 静默时解码仍正常报错
+	if _, _, err := decodeFake(t, []byte("#!SILK_V2\x00\x00"), DecodeOptions{}); !errors.Is(err, ErrInvalidHeader) {
+		t.Fatalf("err = %v, want ErrInvalidHeader", err)
+	}
+}
@@ -0,0 +1,35 @@
+package silk
+
+// native 解码循环用到的底层调用, 默认由 *silk 通过 dllsilk.dll 实现
+// 每次成功的 createDecoder 都必须恰好对应一次 closeDecoder (AbandonOnCancel 放弃的调用除外),
+// 替换为记录调用的实现即可在没有 dll 的环境下检查这一点
+type native interface {
+	createDecoder() (uintptr, error)
+	closeDecoder(handle uintptr) error
+	setSampleRate(handle uintptr, sample int) error
+	setFramesPerPacket(handle uintptr, perPacket int) error
+	decode(handle uintptr, inData []byte, inDataLength int, outData []byte, outDataLength int16) (int, error)
+}
+
+// rateGetter 可选的底层调用, 返回解码器实际使用的输出采样率
+type rateGetter interface {
+	getSampleRate(handle uintptr) (int, error)
+}
+
+// effectiveSampleRate 返回 handle 实际使用的输出采样率, 底层不支持查询时返回 requested
+func effectiveSampleRate(lib native, handle uintptr, requested int) int {
+	if g, ok := lib.(rateGetter); ok {
+		if rate, err := g.getSampleRate(handle); err == nil && rate > 0 {
+			return rate
+		}
+	}
+	return requested
+}
+
+// lib 返回当前使用的底层实现
+func (s *silk) lib() native {
+	if s.native != nil {
+		return s.native
+	}
+	return s
+}
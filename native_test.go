@@ -0,0 +1,298 @@
+package silk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"testing"
+)
+
+// fakeNative 记录调用的 native 实现, 不需要 dll
+// 默认每次 decode 输出一帧(20ms)pcm, 每个采样的值为负载的第一个字节乘以 100
+type fakeNative struct {
+	mu      sync.Mutex
+	next    uintptr
+	rates   map[uintptr]int  // 每个 handle 设置的采样率
+	packets map[uintptr]int  // 每个 handle 设置的 framesPerPacket
+	open    map[uintptr]bool // 尚未关闭的 handle
+	created int
+	closed  int
+	invalid []string // 关闭未知或已关闭的 handle 等错误调用
+
+	createErr  error
+	setRateErr error
+	// decodeFn 替换默认的 decode, call 为从 1 开始的调用序号
+	decodeFn func(call int, in []byte, out []byte, rate int) (int, error)
+	calls    int
+}
+
+func newFakeNative() *fakeNative {
+	return &fakeNative{rates: make(map[uintptr]int), packets: make(map[uintptr]int), open: make(map[uintptr]bool)}
+}
+
+func (f *fakeNative) createDecoder() (uintptr, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.createErr != nil {
+		return 0, f.createErr
+	}
+	f.next++
+	f.created++
+	f.open[f.next] = true
+	return f.next, nil
+}
+
+func (f *fakeNative) closeDecoder(handle uintptr) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.open[handle] {
+		f.invalid = append(f.invalid, fmt.Sprintf("closeDecoder(%d) on a handle that is not open", handle))
+		return nil
+	}
+	delete(f.open, handle)
+	f.closed++
+	return nil
+}
+
+func (f *fakeNative) setSampleRate(handle uintptr, sample int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.open[handle] {
+		f.invalid = append(f.invalid, fmt.Sprintf("setSampleRate(%d) on a handle that is not open", handle))
+	}
+	if f.setRateErr != nil {
+		return f.setRateErr
+	}
+	f.rates[handle] = sample
+	return nil
+}
+
+func (f *fakeNative) setFramesPerPacket(handle uintptr, perPacket int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.packets[handle] = perPacket
+	return nil
+}
+
+func (f *fakeNative) decode(handle uintptr, inData []byte, inDataLength int, outData []byte, outDataLength int16) (int, error) {
+	f.mu.Lock()
+	if !f.open[handle] {
+		f.invalid = append(f.invalid, fmt.Sprintf("decode(%d) on a handle that is not open", handle))
+	}
+	f.calls++
+	call, rate, fn := f.calls, f.rates[handle], f.decodeFn
+	f.mu.Unlock()
+	if fn != nil {
+		return fn(call, inData[:inDataLength], outData, rate)
+	}
+	return fakeFrame(inData[:inDataLength], outData, rate), nil
+}
+
+// fakeFrame 按默认规则向 out 写入一帧 pcm, 返回字节数
+func fakeFrame(in []byte, out []byte, rate int) int {
+	n := rate * FRAME_LENGTH_MS / 1000 * 2
+	for i := 0; i < n; i += 2 {
+		v := int16(in[0]) * 100
+		out[i], out[i+1] = byte(v), byte(v>>8)
+	}
+	return n
+}
+
+// checkLeaks 确认每个 createDecoder 都恰好对应一次 closeDecoder
+func (f *fakeNative) checkLeaks(t *testing.T) {
+	t.Helper()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.created != f.closed || len(f.open) != 0 {
+		t.Errorf("created %d decoders, closed %d, still open: %v", f.created, f.closed, f.open)
+	}
+	for _, msg := range f.invalid {
+		t.Error(msg)
+	}
+}
+
+// rateFake 同时实现 rateGetter, 模拟 dll 忽略请求的采样率, 始终按 effective 输出
+type rateFake struct {
+	*fakeNative
+	effective int
+}
+
+func (f rateFake) setSampleRate(handle uintptr, sample int) error {
+	return f.fakeNative.setSampleRate(handle, f.effective)
+}
+
+func (f rateFake) getSampleRate(handle uintptr) (int, error) {
+	return f.effective, nil
+}
+
+// fakeDecoder 返回使用 lib 的解码器
+func fakeDecoder(lib native) *silk {
+	return &silk{native: lib}
+}
+
+// useFake 让包内函数创建的解码器都使用 lib, 测试结束时恢复
+func useFake(t *testing.T, lib native) {
+	t.Helper()
+	prev := newDecoder
+	newDecoder = func() *silk { return fakeDecoder(lib) }
+	t.Cleanup(func() { newDecoder = prev })
+}
+
+// cancelWriter 写入 n 次之后取消 ctx
+type cancelWriter struct {
+	n      int
+	cancel context.CancelFunc
+}
+
+func (w *cancelWriter) Write(p []byte) (int, error) {
+	if w.n--; w.n == 0 {
+		w.cancel()
+	}
+	return len(p), nil
+}
+
+func TestNoLeakOnSuccess(t *testing.T) {
+	f := newFakeNative()
+	stream := withFooter(buildStream(nil, payloads(10, 30)...))
+	pcm, err := fakeDecoder(f).Decode(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 10 * 640; len(pcm) != want {
+		t.Fatalf("decoded %d bytes, want %d", len(pcm), want)
+	}
+	f.checkLeaks(t)
+	if f.created != 1 {
+		t.Fatalf("created %d decoders, want 1", f.created)
+	}
+}
+
+func TestNoLeakOnDecodeError(t *testing.T) {
+	f := newFakeNative()
+	f.decodeFn = func(call int, in, out []byte, rate int) (int, error) {
+		if call == 3 {
+			return 0, errors.New("bad frame")
+		}
+		return fakeFrame(in, out, rate), nil
+	}
+	stream := buildStream(nil, payloads(5, 30)...)
+	if _, err := fakeDecoder(f).Decode(bytes.NewReader(stream)); err == nil {
+		t.Fatal("Decode succeeded, want the decode error")
+	}
+	f.checkLeaks(t)
+}
+
+func TestNoLeakOnTruncatedStream(t *testing.T) {
+	f := newFakeNative()
+	stream := buildStream(nil, payloads(3, 30)...)
+	stream = stream[:len(stream)-10]
+	if _, err := fakeDecoder(f).Decode(bytes.NewReader(stream)); !errors.Is(err, ErrTruncatedStream) {
+		t.Fatalf("Decode = %v, want ErrTruncatedStream", err)
+	}
+	f.checkLeaks(t)
+}
+
+func TestNoLeakOnSetupError(t *testing.T) {
+	f := newFakeNative()
+	f.setRateErr = errors.New("setSampleRate failed")
+	stream := buildStream(nil, payloads(3, 30)...)
+	if _, err := fakeDecoder(f).Decode(bytes.NewReader(stream)); !errors.Is(err, f.setRateErr) {
+		t.Fatalf("Decode = %v, want the setSampleRate error", err)
+	}
+	f.checkLeaks(t)
+	if f.created != 1 {
+		t.Fatalf("created %d decoders, want 1", f.created)
+	}
+}
+
+func TestNoLeakOnContextCancel(t *testing.T) {
+	f := newFakeNative()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream := buildStream(nil, payloads(10, 30)...)
+	w := &cancelWriter{n: 2, cancel: cancel}
+	err := fakeDecoder(f).DecodeStreamContext(ctx, w, bytes.NewReader(stream), DecodeOptions{})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("DecodeStreamContext = %v, want context.Canceled", err)
+	}
+	f.checkLeaks(t)
+}
+
+func TestNoLeakOnRetryFirstFrame(t *testing.T) {
+	f := newFakeNative()
+	f.decodeFn = func(call int, in, out []byte, rate int) (int, error) {
+		if call == 1 {
+			return 0, errors.New("first decode after create fails")
+		}
+		return fakeFrame(in, out, rate), nil
+	}
+	stream := buildStream(nil, payloads(3, 30)...)
+	if _, err := fakeDecoder(f).DecodeWithOptions(bytes.NewReader(stream), DecodeOptions{RetryFirstFrame: true}); err != nil {
+		t.Fatal(err)
+	}
+	f.checkLeaks(t)
+	if f.created != 2 {
+		t.Fatalf("created %d decoders, want 2 (original and retry)", f.created)
+	}
+}
+
+func TestNoLeakAcrossStreams(t *testing.T) {
+	f := newFakeNative()
+	useFake(t, f)
+	one := withFooter(buildStream(nil, payloads(2, 30)...))
+	two := withFooter(buildStream(nil, payloads(3, 30)...))
+	pcm, err := DecodeAll(bytes.NewReader(append(one, two...)), DecodeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 5 * 640; len(pcm) != want {
+		t.Fatalf("decoded %d bytes, want %d", len(pcm), want)
+	}
+	f.checkLeaks(t)
+}
+
+func TestNoLeakInPackageHelpers(t *testing.T) {
+	f := newFakeNative()
+	useFake(t, f)
+	stream := withFooter(buildStream(nil, payloads(4, 30)...))
+	if _, err := SilkToWavBytes(bytes.NewReader(stream), WavOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecodePlaylist([]io.Reader{bytes.NewReader(stream), bytes.NewReader(stream)}, DecodeOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DecodeFrames(bytes.NewReader(stream), DecodeOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	data, errc := DecodeChan(bytes.NewReader(stream), 1000, DecodeOptions{})
+	for range data {
+	}
+	if err := <-errc; err != nil {
+		t.Fatal(err)
+	}
+	f.checkLeaks(t)
+	// 每次解码创建一个 handle, 播放列表的两项各一个
+	if f.created != 5 {
+		t.Fatalf("created %d decoders, want 5", f.created)
+	}
+}
+
+// samplesNative 依次输出 samples 中的采样, 每次 decode 一帧, 用完之后输出静音
+func samplesNative(samples []int16) *fakeNative {
+	f := newFakeNative()
+	f.decodeFn = func(call int, in, out []byte, rate int) (int, error) {
+		n := rate * FRAME_LENGTH_MS / 1000
+		for i := 0; i < n; i++ {
+			var v int16
+			if j := (call-1)*n + i; j < len(samples) {
+				v = samples[j]
+			}
+			out[2*i], out[2*i+1] = byte(v), byte(v>>8)
+		}
+		return 2 * n, nil
+	}
+	return f
+}
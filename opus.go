@@ -0,0 +1,95 @@
+package silk
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+var (
+	// ErrNotOpusSilk Opus 包不是 SILK-only 模式
+	ErrNotOpusSilk = errors.New("opus packet is not in SILK-only mode")
+	// ErrOpusSilkUnsupported Opus 中的 SILK 码流无法交给 dllsilk.dll 解码
+	ErrOpusSilkUnsupported = errors.New("opus SILK-only payload is not supported by dllsilk.dll")
+)
+
+// OpusMode Opus 编码模式
+type OpusMode int
+
+const (
+	OpusModeSilk OpusMode = iota
+	OpusModeHybrid
+	OpusModeCelt
+)
+
+func (m OpusMode) String() string {
+	switch m {
+	case OpusModeSilk:
+		return "SILK"
+	case OpusModeHybrid:
+		return "Hybrid"
+	case OpusModeCelt:
+		return "CELT"
+	}
+	return fmt.Sprintf("OpusMode(%d)", int(m))
+}
+
+// OpusTOC Opus 包首字节(TOC)的解析结果, 见 RFC 6716 3.1 节
+type OpusTOC struct {
+	Config        int           // 0~31
+	Mode          OpusMode      // 由 Config 决定
+	Bandwidth     int           // 音频带宽(Hz): 4000/6000/8000/12000/20000
+	FrameDuration time.Duration // 每帧时长
+	Stereo        bool
+	FrameCode     int // 0: 1 帧, 1: 2 帧等长, 2: 2 帧不等长, 3: 任意帧数
+}
+
+func (t OpusTOC) String() string {
+	return fmt.Sprintf("config=%d mode=%s bandwidth=%d frame=%s stereo=%t code=%d",
+		t.Config, t.Mode, t.Bandwidth, t.FrameDuration, t.Stereo, t.FrameCode)
+}
+
+// ParseOpusTOC 解析 Opus 包的 TOC 字节
+func ParseOpusTOC(toc byte) OpusTOC {
+	t := OpusTOC{
+		Config:    int(toc >> 3),
+		Stereo:    toc&0x04 != 0,
+		FrameCode: int(toc & 0x03),
+	}
+	silkFrames := [4]time.Duration{10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 60 * time.Millisecond}
+	celtFrames := [4]time.Duration{2500 * time.Microsecond, 5 * time.Millisecond, 10 * time.Millisecond, 20 * time.Millisecond}
+	switch {
+	case t.Config < 12: // NB/MB/WB 各 4 个
+		t.Mode = OpusModeSilk
+		t.Bandwidth = [3]int{4000, 6000, 8000}[t.Config/4]
+		t.FrameDuration = silkFrames[t.Config%4]
+	case t.Config < 16: // SWB/FB 各 2 个
+		t.Mode = OpusModeHybrid
+		t.Bandwidth = [2]int{12000, 20000}[(t.Config-12)/2]
+		t.FrameDuration = silkFrames[(t.Config-12)%2]
+	default: // NB/WB/SWB/FB 各 4 个
+		t.Mode = OpusModeCelt
+		t.Bandwidth = [4]int{4000, 8000, 12000, 20000}[(t.Config-16)/4]
+		t.FrameDuration = celtFrames[(t.Config-16)%4]
+	}
+	return t
+}
+
+// DecodeOpusSilk 读取一个 Opus 包并确认其为 SILK-only 模式
+//
+// Opus 中的 SILK 层与 SILK_V3 SDK 的码流并不兼容: 它与 CELT 共用 range coder,
+// 没有长度前缀, 并且增加了 LBRR/立体声预测等字段, dllsilk.dll 无法直接解码.
+// 因此 SILK-only 的包返回 ErrOpusSilkUnsupported, 其他模式返回 ErrNotOpusSilk,
+// 调用方可据此选择 libopus 等解码器.
+func DecodeOpusSilk(src io.Reader) ([]byte, error) {
+	var toc [1]byte
+	if _, err := io.ReadFull(src, toc[:]); err != nil {
+		return nil, fmt.Errorf("failed to read opus toc: %w", err)
+	}
+	t := ParseOpusTOC(toc[0])
+	if t.Mode != OpusModeSilk {
+		return nil, fmt.Errorf("%w: %s", ErrNotOpusSilk, t)
+	}
+	return nil, fmt.Errorf("%w: %s", ErrOpusSilkUnsupported, t)
+}
@@ -0,0 +1,62 @@
+package silk
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseOpusTOC(t *testing.T) {
+	for _, tc := range []struct {
+		toc  byte
+		want OpusTOC
+	}{
+		// RFC 6716 3.1 的配置表
+		{0<<3 | 0, OpusTOC{Config: 0, Mode: OpusModeSilk, Bandwidth: 4000, FrameDuration: 10 * time.Millisecond}},
+		{1<<3 | 0x04, OpusTOC{Config: 1, Mode: OpusModeSilk, Bandwidth: 4000, FrameDuration: 20 * time.Millisecond, Stereo: true}},
+		{7<<3 | 1, OpusTOC{Config: 7, Mode: OpusModeSilk, Bandwidth: 6000, FrameDuration: 60 * time.Millisecond, FrameCode: 1}},
+		{9<<3 | 3, OpusTOC{Config: 9, Mode: OpusModeSilk, Bandwidth: 8000, FrameDuration: 20 * time.Millisecond, FrameCode: 3}},
+		{12<<3 | 0, OpusTOC{Config: 12, Mode: OpusModeHybrid, Bandwidth: 12000, FrameDuration: 10 * time.Millisecond}},
+		{15<<3 | 2, OpusTOC{Config: 15, Mode: OpusModeHybrid, Bandwidth: 20000, FrameDuration: 20 * time.Millisecond, FrameCode: 2}},
+		{16<<3 | 0, OpusTOC{Config: 16, Mode: OpusModeCelt, Bandwidth: 4000, FrameDuration: 2500 * time.Microsecond}},
+		{23<<3 | 0, OpusTOC{Config: 23, Mode: OpusModeCelt, Bandwidth: 8000, FrameDuration: 20 * time.Millisecond}},
+		{31<<3 | 0x07, OpusTOC{Config: 31, Mode: OpusModeCelt, Bandwidth: 20000, FrameDuration: 20 * time.Millisecond, Stereo: true, FrameCode: 3}},
+	} {
+		if got := ParseOpusTOC(tc.toc); got != tc.want {
+			t.Errorf("ParseOpusTOC(%#02x) = %s, want %s", tc.toc, got, tc.want)
+		}
+	}
+}
+
+func TestParseOpusTOCModes(t *testing.T) {
+	for toc := 0; toc < 256; toc++ {
+		got := ParseOpusTOC(byte(toc))
+		var want OpusMode
+		switch config := toc >> 3; {
+		case config < 12:
+			want = OpusModeSilk
+		case config < 16:
+			want = OpusModeHybrid
+		default:
+			want = OpusModeCelt
+		}
+		if got.Mode != want || got.Bandwidth == 0 || got.FrameDuration == 0 {
+			t.Errorf("ParseOpusTOC(%#02x) = %s, want mode %s", toc, got, want)
+		}
+	}
+}
+
+func TestDecodeOpusSilk(t *testing.T) {
+	if _, err := DecodeOpusSilk(bytes.NewReader([]byte{1 << 3, 0xAA})); !errors.Is(err, ErrOpusSilkUnsupported) {
+		t.Errorf("SILK-only packet: err = %v, want ErrOpusSilkUnsupported", err)
+	}
+	for _, toc := range []byte{12 << 3, 20 << 3} {
+		if _, err := DecodeOpusSilk(bytes.NewReader([]byte{toc, 0xAA})); !errors.Is(err, ErrNotOpusSilk) {
+			t.Errorf("toc %#02x: err = %v, want ErrNotOpusSilk", toc, err)
+		}
+	}
+	if _, err := DecodeOpusSilk(bytes.NewReader(nil)); err == nil {
+		t.Error("empty packet: want an error")
+	}
+}
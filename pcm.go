@@ -0,0 +1,108 @@
+package silk
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+)
+
+// SilencePCM 返回时长 d 的 16bit 静音 pcm, 采样数向下取整, channels 个声道交织
+func SilencePCM(d time.Duration, rate, channels int) []byte {
+	if channels <= 0 {
+		channels = 1
+	}
+	return make([]byte, silenceLen(d, rate)*channels)
+}
+
+// bytesToSamples 将小端序 16bit pcm 转换为采样, 末尾不完整的字节被丢弃
+func bytesToSamples(pcm []byte) []int16 {
+	samples := make([]int16, len(pcm)/2)
+	for i := range samples {
+		samples[i] = int16(binary.LittleEndian.Uint16(pcm[2*i:]))
+	}
+	return samples
+}
+
+// samplesToBytes 将采样转换为小端序 16bit pcm
+func samplesToBytes(samples []int16) []byte {
+	pcm := make([]byte, len(samples)*2)
+	for i, v := range samples {
+		binary.LittleEndian.PutUint16(pcm[2*i:], uint16(v))
+	}
+	return pcm
+}
+
+// clip16 四舍五入并饱和到 ±32767, 避免溢出回绕
+func clip16(v float64) int16 {
+	v = math.Round(v)
+	if v > math.MaxInt16 {
+		return math.MaxInt16
+	}
+	if v < -math.MaxInt16 {
+		return -math.MaxInt16
+	}
+	return int16(v)
+}
+
+// applyGain 按 factor 原地缩放小端序 16bit pcm
+func applyGain(pcm []byte, factor float64) {
+	for i := 0; i+1 < len(pcm); i += 2 {
+		v := int16(binary.LittleEndian.Uint16(pcm[i:]))
+		binary.LittleEndian.PutUint16(pcm[i:], uint16(clip16(float64(v)*factor)))
+	}
+}
+
+// padToSecond 返回长度为 n 字节的单声道 16bit pcm 补齐到整秒还需要的字节数
+func padToSecond(n int64, rate int) int64 {
+	second := int64(rate) * 2
+	if rem := n % second; rem != 0 {
+		return second - rem
+	}
+	return 0
+}
+
+// frameChannels 根据一次 Decode 输出的字节数 n 推断声道数, frameBytes 为单声道一次最多输出的字节数
+// 不超过一帧视为单声道, 一帧的整数倍视为对应的声道数, 否则返回 0 表示无法识别
+func frameChannels(n, frameBytes int) int {
+	switch {
+	case n <= frameBytes:
+		return 1
+	case n%frameBytes == 0:
+		return n / frameBytes
+	}
+	return 0
+}
+
+// downmix 将交织的 channels 声道 16bit pcm 原地平均为单声道, 返回单声道的字节数
+func downmix(pcm []byte, channels int) int {
+	frames := len(pcm) / 2 / channels
+	for i := 0; i < frames; i++ {
+		var sum int
+		for c := 0; c < channels; c++ {
+			sum += int(int16(binary.LittleEndian.Uint16(pcm[(i*channels+c)*2:])))
+		}
+		binary.LittleEndian.PutUint16(pcm[i*2:], uint16(int16(sum/channels)))
+	}
+	return frames * 2
+}
+
+// countClipped 返回 pcm 中达到 ±32767 的采样数
+func countClipped(pcm []byte) int {
+	var n int
+	for i := 0; i+1 < len(pcm); i += 2 {
+		if v := int16(binary.LittleEndian.Uint16(pcm[i:])); v >= math.MaxInt16 || v <= -math.MaxInt16 {
+			n++
+		}
+	}
+	return n
+}
+
+// trimPCM 去掉单声道 16bit pcm 开头 startMs、结尾 endMs 毫秒, 超过总长度时返回空和 true
+func trimPCM(pcm []byte, startMs, endMs, rate int) ([]byte, bool) {
+	start := silenceLen(time.Duration(startMs)*time.Millisecond, rate)
+	end := silenceLen(time.Duration(endMs)*time.Millisecond, rate)
+	if start+end >= len(pcm) {
+		return pcm[:0], true
+	}
+	return pcm[start : len(pcm)-end], false
+}
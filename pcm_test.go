@@ -0,0 +1,194 @@
+package silk
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestApplyGain(t *testing.T) {
+	pcm := samplesToBytes([]int16{1000, -1000, 0, 12345})
+	applyGain(pcm, math.Pow(10, 6.0/20))
+	got := bytesToSamples(pcm)
+	for i, want := range []int16{1000, -1000, 0, 12345} {
+		// +6 dB 约为 1.995 倍
+		if ratio := float64(got[i]) / float64(want); want != 0 && math.Abs(ratio-2) > 0.01 {
+			t.Errorf("sample %d: %d -> %d, ratio %.3f, want about 2", i, want, got[i], ratio)
+		}
+	}
+	if got[2] != 0 {
+		t.Errorf("silence became %d", got[2])
+	}
+}
+
+func TestApplyGainSaturates(t *testing.T) {
+	pcm := samplesToBytes([]int16{20000, -20000, 32767, -32768})
+	applyGain(pcm, 4)
+	for i, v := range bytesToSamples(pcm) {
+		want := int16(32767)
+		if i%2 == 1 {
+			want = -32767
+		}
+		if v != want {
+			t.Errorf("sample %d = %d, want %d (no wrap-around)", i, v, want)
+		}
+	}
+}
+
+func TestDecodeGain(t *testing.T) {
+	stream := withFooter(buildStream(nil, payloads(2, 30)...))
+	pcm, info, err := decodeFake(t, stream, DecodeOptions{Gain: 6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 'a'*100 = 9700, 'b'*100 = 9800
+	factor := math.Pow(10, 6.0/20)
+	want := []int16{clip16(9700 * factor), clip16(9800 * factor)}
+	if got := firstSamples(pcm, 640); !equalSamples(got, want) {
+		t.Fatalf("frames %v, want %v", got, want)
+	}
+	if info.Clipped != 0 {
+		t.Fatalf("Clipped = %d, want 0", info.Clipped)
+	}
+	_, info, err = decodeFake(t, stream, DecodeOptions{Gain: 12})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Clipped != info.Samples {
+		t.Fatalf("Clipped = %d, want all %d samples", info.Clipped, info.Samples)
+	}
+}
+
+func TestCountClipped(t *testing.T) {
+	pcm := samplesToBytes([]int16{32767, -32767, -32768, 32766, 0, 100})
+	if n := countClipped(pcm); n != 3 {
+		t.Fatalf("countClipped = %d, want 3", n)
+	}
+}
+
+func TestDecodeReportsClipping(t *testing.T) {
+	f := levelsNative(1000, 20000, 30000, -25000)
+	stream := withFooter(buildStream(nil, payloads(4, 30)...))
+	_, info, err := fakeDecoder(f).DecodeWithInfo(bytes.NewReader(stream), DecodeOptions{Gain: 6})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.checkLeaks(t)
+	// +6 dB 之后后三帧超出量程
+	if info.Clipped != 3*320 || info.Samples != 4*320 {
+		t.Fatalf("Clipped = %d of %d samples, want %d of %d", info.Clipped, info.Samples, 3*320, 4*320)
+	}
+	if p := info.ClipPercent(); p != 75 {
+		t.Fatalf("ClipPercent = %v, want 75", p)
+	}
+	if (DecodeInfo{}).ClipPercent() != 0 {
+		t.Fatal("ClipPercent of an empty decode is not 0")
+	}
+}
+
+func TestSilencePCM(t *testing.T) {
+	for _, tc := range []struct {
+		d        time.Duration
+		rate, ch int
+		want     int
+	}{
+		{20 * time.Millisecond, 8000, 1, 320},
+		{20 * time.Millisecond, 16000, 1, 640},
+		{20 * time.Millisecond, 24000, 1, 960},
+		{time.Second, 44100, 2, 176400},
+		{time.Second, 48000, 1, 96000},
+		{500 * time.Millisecond, 12000, 1, 12000},
+		{time.Millisecond / 2, 16000, 1, 16}, // 8 个采样
+		{time.Microsecond * 30, 16000, 1, 0}, // 不足一个采样时向下取整
+		{0, 16000, 1, 0},
+		{-time.Second, 16000, 1, 0},
+		{10 * time.Millisecond, 16000, 0, 320}, // channels 为 0 时按单声道
+	} {
+		pcm := SilencePCM(tc.d, tc.rate, tc.ch)
+		if len(pcm) != tc.want {
+			t.Errorf("SilencePCM(%s, %d, %d) = %d bytes, want %d", tc.d, tc.rate, tc.ch, len(pcm), tc.want)
+		}
+		for _, b := range pcm {
+			if b != 0 {
+				t.Fatalf("SilencePCM(%s, %d, %d) is not silent", tc.d, tc.rate, tc.ch)
+			}
+		}
+	}
+}
+
+func TestTrimPCMExact(t *testing.T) {
+	for _, rate := range []int{8000, 12000, 16000, 24000, 44100, 48000} {
+		n := rate / 2
+		ramp := make([]int16, n)
+		for i := range ramp {
+			ramp[i] = int16(i)
+		}
+		got, all := trimPCM(samplesToBytes(ramp), 30, 7, rate)
+		start, end := rate*30/1000, rate*7/1000
+		samples := bytesToSamples(got)
+		if all || len(samples) != n-start-end {
+			t.Fatalf("rate %d: %d samples (all %v), want %d", rate, len(samples), all, n-start-end)
+		}
+		if samples[0] != int16(start) || samples[len(samples)-1] != int16(n-end-1) {
+			t.Fatalf("rate %d: kept samples %d..%d, want %d..%d", rate, samples[0], samples[len(samples)-1], start, n-end-1)
+		}
+	}
+}
+
+func TestTrimPCMAll(t *testing.T) {
+	pcm := make([]byte, 16000*2/10) // 100ms @16kHz
+	for _, tt := range []struct{ start, end int }{{100, 0}, {0, 100}, {60, 60}, {1000, 0}} {
+		got, all := trimPCM(pcm, tt.start, tt.end, 16000)
+		if !all || len(got) != 0 {
+			t.Errorf("trim %d+%d ms of 100ms: %d bytes, all %v", tt.start, tt.end, len(got), all)
+		}
+	}
+	if got, all := trimPCM(pcm, 50, 49, 16000); all || len(got) != 32 {
+		t.Errorf("trim 99ms of 100ms: %d bytes, all %v, want 32 bytes", len(got), all)
+	}
+}
+
+func TestDecodeTrim(t *testing.T) {
+	stream := buildStream(nil, payloads(5, 4)...)
+	opts := DecodeOptions{SampleRate: 16000, TrimStartMs: 20, TrimEndMs: 20}
+	pcm, info, err := decodeFake(t, stream, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	frameBytes := 16000 * 20 / 1000 * 2
+	if info.TrimmedAll || len(pcm) != 3*frameBytes {
+		t.Fatalf("got %d bytes (TrimmedAll %v), want %d", len(pcm), info.TrimmedAll, 3*frameBytes)
+	}
+	if got, want := firstSamples(pcm, frameBytes), wantFrames(5)[1:4]; !equalSamples(got, want) {
+		t.Fatalf("kept frames %v, want %v", got, want)
+	}
+
+	opts.TrimStartMs, opts.TrimEndMs = 60, 60
+	pcm, info, err = decodeFake(t, stream, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.TrimmedAll || len(pcm) != 0 {
+		t.Fatalf("over-trim: %d bytes, TrimmedAll %v", len(pcm), info.TrimmedAll)
+	}
+}
+
+func TestDecodeBothTrimHeader(t *testing.T) {
+	f := newFakeNative()
+	stream := buildStream(nil, payloads(5, 4)...)
+	opts := DecodeOptions{SampleRate: 24000, TrimStartMs: 10, TrimEndMs: 30}
+	wav, pcm, _, err := fakeDecoder(f).DecodeBoth(bytes.NewReader(stream), opts)
+	f.checkLeaks(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := (5*20 - 40) * 24000 / 1000 * 2
+	if len(pcm) != want {
+		t.Fatalf("pcm %d bytes, want %d", len(pcm), want)
+	}
+	format, data := parseWav(t, wav)
+	if format.sampleRate != 24000 || !bytes.Equal(data, pcm) {
+		t.Fatalf("wav header rate %d, data %d bytes, want 24000 and %d", format.sampleRate, len(data), len(pcm))
+	}
+}
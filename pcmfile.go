@@ -0,0 +1,98 @@
+package silk
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// PCMMetadata SilkToPCMFile 写出的 json 元数据
+type PCMMetadata struct {
+	Rate       int   `json:"rate"`
+	Channels   int   `json:"channels"`
+	Bits       int   `json:"bits"`
+	Frames     int   `json:"frames"` // 采样帧数, 即每个声道的采样数
+	DurationMs int64 `json:"duration_ms"`
+}
+
+// SilkToPCMFile 解码 srcPath, 将小端序 16bit 裸 pcm 写入 dstPath, 元数据写入 dstPath+".json"
+// 两个文件都先写入同目录的临时文件再改名, 失败时不会留下不完整的输出
+func SilkToPCMFile(srcPath, dstPath string, opts DecodeOptions) error {
+	opts = opts.withDefaults()
+	if err := opts.requireLinear(); err != nil {
+		return err
+	}
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	pcm, info, err := newDecoder().DecodeWithInfo(src, opts)
+	if err != nil {
+		return fmt.Errorf("failed to decode %s: %w", srcPath, err)
+	}
+	rate := opts.decodedRate(info)
+	frames := len(pcm) / 2
+	meta, err := json.Marshal(PCMMetadata{
+		Rate:       rate,
+		Channels:   1,
+		Bits:       16,
+		Frames:     frames,
+		DurationMs: int64(frames) * 1000 / int64(rate),
+	})
+	if err != nil {
+		return err
+	}
+	pcmTmp, err := writeTemp(dstPath, pcm)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(pcmTmp)
+	metaTmp, err := writeTemp(dstPath+".json", meta)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(metaTmp)
+	if err = os.Rename(pcmTmp, dstPath); err != nil {
+		return err
+	}
+	if err = os.Rename(metaTmp, dstPath+".json"); err != nil {
+		os.Remove(dstPath) // 没有元数据的 pcm 同样不完整
+		return err
+	}
+	return nil
+}
+
+// writeTemp 将 data 写入 path 同目录下的临时文件, 返回临时文件路径
+func writeTemp(path string, data []byte) (string, error) {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	if _, err = tmp.Write(data); err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return "", fmt.Errorf("failed to write %s: %w", tmp.Name(), err)
+	}
+	return tmp.Name(), nil
+}
+
+// linear16Rate 语音识别接口(Google/Azure Speech-to-Text) LINEAR16 常用的采样率
+const linear16Rate = 16000
+
+// SilkToLinear16 解码为 16kHz 单声道的 LINEAR16(小端序 16bit, 没有文件头), 同时返回采样率 16000
+// dll 改用了其他采样率时会重采样到 16000
+func SilkToLinear16(src io.Reader) ([]byte, int, error) {
+	pcm, err := newDecoder().DecodeWithOptions(src, DecodeOptions{SampleRate: linear16Rate, ResampleTo: linear16Rate})
+	if err != nil {
+		return nil, 0, err
+	}
+	return pcm, linear16Rate, nil
+}
@@ -0,0 +1,101 @@
+package silk
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// dirEntries 返回 dir 下按名称排序的文件名, 以空格分隔
+func dirEntries(t *testing.T, dir string) string {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	sort.Strings(names)
+	return strings.Join(names, " ")
+}
+
+func TestSilkToPCMFile(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "in.silk")
+	dst := filepath.Join(dir, "out.pcm")
+	if err := os.WriteFile(src, withFooter(buildStream(nil, payloads(3, 4)...)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dst, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f := newFakeNative()
+	useFake(t, f)
+	if err := SilkToPCMFile(src, dst, DecodeOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	f.checkLeaks(t)
+
+	// 已有的目标文件被整体替换, 不留下临时文件
+	pcm, err := os.ReadFile(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pcm) != 3*640 || !equalSamples(firstSamples(pcm, 640), wantFrames(3)) {
+		t.Fatalf("%d pcm bytes, frames %v", len(pcm), firstSamples(pcm, 640))
+	}
+	data, err := os.ReadFile(dst + ".json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var meta PCMMetadata
+	if err = json.Unmarshal(data, &meta); err != nil {
+		t.Fatal(err)
+	}
+	if want := (PCMMetadata{Rate: 16000, Channels: 1, Bits: 16, Frames: 960, DurationMs: 60}); meta != want {
+		t.Fatalf("metadata %+v, want %+v", meta, want)
+	}
+	if got, want := dirEntries(t, dir), "in.silk out.pcm out.pcm.json"; got != want {
+		t.Fatalf("files %q, want %q", got, want)
+	}
+}
+
+func TestSilkToPCMFileDecodeError(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "in.silk")
+	dst := filepath.Join(dir, "out.pcm")
+	if err := os.WriteFile(src, withFooter(buildStream(nil, payloads(3, 4)...)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(dst, []byte("old"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	errDecode := errors.New("decode failed")
+	f := newFakeNative()
+	f.decodeFn = func(call int, in, out []byte, rate int) (int, error) {
+		if call == 3 {
+			return 0, errDecode
+		}
+		return fakeFrame(in, out, rate), nil
+	}
+	useFake(t, f)
+	if err := SilkToPCMFile(src, dst, DecodeOptions{}); !errors.Is(err, errDecode) {
+		t.Fatalf("err = %v, want %v", err, errDecode)
+	}
+	f.checkLeaks(t)
+
+	// 解码失败时目标文件保持原样, 没有部分写入的 pcm 或元数据
+	if data, err := os.ReadFile(dst); err != nil || !bytes.Equal(data, []byte("old")) {
+		t.Fatalf("target = %q, %v, want the old content", data, err)
+	}
+	if got, want := dirEntries(t, dir), "in.silk out.pcm"; got != want {
+		t.Fatalf("files %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,77 @@
+package silk
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"time"
+)
+
+// DecodePlaylist 依次解码 srcs 并拼接为一段连续的 pcm, 相邻两段之间插入 opts.GapBetween 的静音,
+// 或者在 opts.Crossfade 时长内线性交叉淡化(设置了 GapBetween 时不做交叉淡化)
+// 所有输入使用同一组 opts 解码(含 ResampleTo). 每段各自创建解码器并检测 dll 实际输出的采样率,
+// 与第一段不同时按 opts 的重采样设置转换为第一段的采样率, 因此输出采样率一致
+func DecodePlaylist(srcs []io.Reader, opts DecodeOptions) ([]byte, error) {
+	opts = opts.withDefaults()
+	if err := opts.requireLinear(); err != nil {
+		return nil, err
+	}
+	decoder := newDecoder()
+	var gap []byte
+	var fade, rate int
+	var out []byte
+	for i, src := range srcs {
+		pcm, info, err := decoder.DecodeWithInfo(src, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode playlist item %d: %w", i, err)
+		}
+		if itemRate := opts.decodedRate(info); i > 0 && itemRate != rate {
+			samples, err := opts.resampler().Resample(bytesToSamples(pcm), itemRate, rate)
+			if err != nil {
+				return nil, fmt.Errorf("failed to resample playlist item %d from %d to %d: %w", i, itemRate, rate, err)
+			}
+			pcm = samplesToBytes(samples)
+		}
+		switch {
+		case i == 0:
+			// 静音和淡化长度按 dll 实际输出的采样率计算
+			rate = opts.decodedRate(info)
+			gap = SilencePCM(opts.GapBetween, rate, 1)
+			fade = silenceLen(opts.Crossfade, rate) / 2
+			out = append(out, pcm...)
+		case len(gap) == 0 && fade > 0:
+			out = crossfadePCM(out, pcm, fade)
+		default:
+			out = append(out, gap...)
+			out = append(out, pcm...)
+		}
+	}
+	return out, nil
+}
+
+// crossfadePCM 将 next 拼接到 out 之后, 两者重叠 fade 个采样并线性交叉淡化
+// 任一段短于 fade 时按较短的一段重叠
+func crossfadePCM(out, next []byte, fade int) []byte {
+	if fade > len(out)/2 {
+		fade = len(out) / 2
+	}
+	if fade > len(next)/2 {
+		fade = len(next) / 2
+	}
+	tail := out[len(out)-fade*2:]
+	for i := 0; i < fade; i++ {
+		a := float64(int16(binary.LittleEndian.Uint16(tail[2*i:])))
+		b := float64(int16(binary.LittleEndian.Uint16(next[2*i:])))
+		t := float64(i+1) / float64(fade+1)
+		binary.LittleEndian.PutUint16(tail[2*i:], uint16(clip16(a*(1-t)+b*t)))
+	}
+	return append(out, next[fade*2:]...)
+}
+
+// silenceLen 返回单声道 16bit pcm 下时长 d 对应的字节数
+func silenceLen(d time.Duration, sampleRate int) int {
+	if d <= 0 {
+		return 0
+	}
+	return int(int64(d)*int64(sampleRate)/int64(time.Second)) * 2
+}
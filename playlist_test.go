@@ -0,0 +1,102 @@
+package silk
+
+import (
+	"bytes"
+	"io"
+	"math"
+	"testing"
+	"time"
+)
+
+// constPCM 返回 n 个值为 v 的采样
+func constPCM(n int, v int16) []byte {
+	s := make([]int16, n)
+	for i := range s {
+		s[i] = v
+	}
+	return samplesToBytes(s)
+}
+
+func TestCrossfadePCM(t *testing.T) {
+	out := crossfadePCM(constPCM(10, 1000), constPCM(10, -1000), 4)
+	got := bytesToSamples(out)
+	if len(got) != 16 {
+		t.Fatalf("%d samples, want 16 (4 overlapped)", len(got))
+	}
+	for i := 0; i < 6; i++ {
+		if got[i] != 1000 {
+			t.Fatalf("sample %d = %d before the join, want 1000", i, got[i])
+		}
+	}
+	// 重叠区是两段的加权平均, 权重从前一段线性过渡到后一段
+	for i := 0; i < 4; i++ {
+		w := float64(i+1) / 5
+		if want := clip16(1000*(1-w) - 1000*w); got[6+i] != want {
+			t.Errorf("join sample %d = %d, want %d", i, got[6+i], want)
+		}
+	}
+	for i := 10; i < 16; i++ {
+		if got[i] != -1000 {
+			t.Fatalf("sample %d = %d after the join, want -1000", i, got[i])
+		}
+	}
+}
+
+func TestCrossfadePCMMidpoint(t *testing.T) {
+	// 奇数长度的淡化中点权重为 1/2, 正好是两段的平均
+	got := bytesToSamples(crossfadePCM(constPCM(4, 3000), constPCM(4, 1000), 3))
+	if got[1+1] != 2000 {
+		t.Fatalf("midpoint = %d, want 2000 in %v", got[2], got)
+	}
+}
+
+func TestCrossfadePCMShortSegment(t *testing.T) {
+	// 淡化长度超过任一段时最多重叠较短的一整段, 不越界
+	for _, tc := range []struct{ a, b, fade, want int }{
+		{2, 10, 8, 10},
+		{10, 3, 8, 10},
+		{1, 1, 8, 1},
+		{0, 5, 4, 5},
+	} {
+		out := crossfadePCM(constPCM(tc.a, 500), constPCM(tc.b, 500), tc.fade)
+		if got := len(out) / 2; got != tc.want {
+			t.Errorf("%d+%d samples with fade %d: %d samples, want %d", tc.a, tc.b, tc.fade, got, tc.want)
+		}
+	}
+}
+
+func TestDecodePlaylistCrossfade(t *testing.T) {
+	useFake(t, levelsNative(8000, 8000, -8000, -8000))
+	stream := withFooter(buildStream(nil, payloads(2, 30)...))
+	srcs := []io.Reader{bytes.NewReader(stream), bytes.NewReader(stream)}
+	pcm, err := DecodePlaylist(srcs, DecodeOptions{Crossfade: 10 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 10ms 淡化在 16000 Hz 下重叠 160 个采样
+	got := bytesToSamples(pcm)
+	if want := 4*320 - 160; len(got) != want {
+		t.Fatalf("%d samples, want %d", len(got), want)
+	}
+	if got[640-160-1] != 8000 || got[640] != -8000 {
+		t.Fatalf("samples around the join: %d, %d", got[640-160-1], got[640])
+	}
+	mid := float64(got[640-80])
+	if math.Abs(mid) > 8000/80+1 {
+		t.Fatalf("join midpoint = %v, want about 0", mid)
+	}
+}
+
+func TestDecodePlaylistGap(t *testing.T) {
+	useFake(t, newFakeNative())
+	stream := withFooter(buildStream(nil, payloads(1, 30)...))
+	srcs := []io.Reader{bytes.NewReader(stream), bytes.NewReader(stream)}
+	pcm, err := DecodePlaylist(srcs, DecodeOptions{SampleRate: 24000, GapBetween: 100 * time.Millisecond, Crossfade: time.Second})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// GapBetween 优先于 Crossfade, 静音按 24000 Hz 计算
+	if want := 2*960 + 4800; len(pcm) != want {
+		t.Fatalf("%d bytes, want %d", len(pcm), want)
+	}
+}
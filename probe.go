@@ -0,0 +1,51 @@
+package silk
+
+import (
+	"errors"
+	"io"
+	"time"
+)
+
+// SilkInfo 不解码即可得到的 silk 流信息, 见 Probe
+type SilkInfo struct {
+	Blocks   int           // block 数, 包括长度为 0 的 block
+	Frames   int           // 非空 block 数
+	Duration time.Duration // 按每个非空 block 一帧(20ms)计算的时长
+	Footer   bool          // 是否以 footer 结束
+	// DeclaredDuration 文件头之后 TDUR 扩展块声明的时长, 没有扩展块时等于 Duration
+	DeclaredDuration time.Duration
+	// HasDeclaredDuration 是否有 TDUR 扩展块
+	HasDeclaredDuration bool
+}
+
+// Probe 只读取 block 长度计算 silk 流的信息, 不调用 dll
+// 有 TDUR 扩展块时与按帧数计算的时长比较, 相差超过两帧(40ms)时记录警告, 通常说明文件被截断或拼接过
+func Probe(src io.Reader) (SilkInfo, error) {
+	var info SilkInfo
+	fr, err := NewFrameReader(src)
+	if err != nil {
+		return info, err
+	}
+	for {
+		frame, err := fr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return info, err
+		}
+		info.Blocks++
+		if len(frame) > 0 {
+			info.Frames++
+		}
+	}
+	info.Footer = fr.Footer()
+	info.Duration = time.Duration(info.Frames) * FRAME_LENGTH_MS * time.Millisecond
+	info.DeclaredDuration, info.HasDeclaredDuration = fr.DeclaredDuration()
+	if !info.HasDeclaredDuration {
+		info.DeclaredDuration = info.Duration
+	} else if diff := info.DeclaredDuration - info.Duration; diff > defaultDurationTolerance || diff < -defaultDurationTolerance {
+		logger.Warn("silk header declares duration %s, frames add up to %s", info.DeclaredDuration, info.Duration)
+	}
+	return info, nil
+}
@@ -0,0 +1,127 @@
+package silk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// durationExt 生成声明 ms 毫秒的 TDUR 扩展块
+func durationExt(ms uint32) []byte {
+	ext := append([]byte(durationMarker), 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(ext[len(durationMarker):], ms)
+	return ext
+}
+
+func TestProbeWithoutExtendedHeader(t *testing.T) {
+	stream := withFooter(buildStream(nil, payloads(5, 30)...))
+	info, err := Probe(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Frames != 5 || info.Blocks != 5 || !info.Footer {
+		t.Fatalf("Probe = %+v, want 5 frames with footer", info)
+	}
+	if info.Duration != 100*time.Millisecond {
+		t.Fatalf("Duration = %s, want 100ms", info.Duration)
+	}
+	if info.HasDeclaredDuration || info.DeclaredDuration != info.Duration {
+		t.Fatalf("DeclaredDuration = %s (has=%v), want computed duration", info.DeclaredDuration, info.HasDeclaredDuration)
+	}
+}
+
+func TestProbeExtendedHeader(t *testing.T) {
+	stream := buildStream(durationExt(100), payloads(5, 30)...)
+	var info SilkInfo
+	var err error
+	logs := captureLogs(func() { info, err = Probe(bytes.NewReader(stream)) })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.HasDeclaredDuration || info.DeclaredDuration != 100*time.Millisecond {
+		t.Fatalf("DeclaredDuration = %s (has=%v), want 100ms", info.DeclaredDuration, info.HasDeclaredDuration)
+	}
+	if info.Frames != 5 {
+		t.Fatalf("Frames = %d, want 5: extension must not be read as a block", info.Frames)
+	}
+	if logs.contains("declares duration") {
+		t.Fatal("matching declared duration was reported as a mismatch")
+	}
+}
+
+func TestProbeExtendedHeaderMismatch(t *testing.T) {
+	stream := buildStream(durationExt(1000), payloads(5, 30)...)
+	logs := captureLogs(func() {
+		if _, err := Probe(bytes.NewReader(stream)); err != nil {
+			t.Fatal(err)
+		}
+	})
+	if !logs.contains("declares duration 1s") {
+		t.Fatalf("no mismatch warning, logs: %q", logs.lines)
+	}
+}
+
+func TestProbeTruncatedExtendedHeader(t *testing.T) {
+	stream := append([]byte(Header), durationMarker+"\x01"...)
+	if _, err := Probe(bytes.NewReader(stream)); !errors.Is(err, ErrTruncatedHeader) {
+		t.Fatalf("Probe = %v, want ErrTruncatedHeader", err)
+	}
+}
+
+// lengthTable 生成 TLEN 长度表扩展块和之后拼接的负载
+func lengthTable(frames ...[]byte) []byte {
+	ext := append([]byte(lengthTableMarker), 0, 0, 0, 0)
+	binary.LittleEndian.PutUint32(ext[len(lengthTableMarker):], uint32(len(frames)))
+	for _, f := range frames {
+		ext = append(ext, 0, 0)
+		binary.LittleEndian.PutUint16(ext[len(ext)-2:], uint16(len(f)))
+	}
+	for _, f := range frames {
+		ext = append(ext, f...)
+	}
+	return ext
+}
+
+func TestProbeLengthTable(t *testing.T) {
+	frames := [][]byte{bytes.Repeat([]byte{1}, 256), {}, bytes.Repeat([]byte{2}, 40), bytes.Repeat([]byte{3}, 7)}
+	stream := buildStream(lengthTable(frames...))
+	fr, err := NewFrameReader(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range frames {
+		got, err := fr.Next()
+		if err != nil {
+			t.Fatalf("frame %d: %v", i, err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Fatalf("frame %d = %d bytes, want %d", i, len(got), len(want))
+		}
+	}
+	if _, err := fr.Next(); err != io.EOF {
+		t.Fatalf("after table: %v, want io.EOF", err)
+	}
+}
+
+func TestProbeLengthTableWithDuration(t *testing.T) {
+	stream := buildStream(append(durationExt(60), lengthTable(payloads(3, 20)...)...))
+	info, err := Probe(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Frames != 3 || info.DeclaredDuration != 60*time.Millisecond {
+		t.Fatalf("Probe = %+v, want 3 frames declaring 60ms", info)
+	}
+}
+
+func TestProbeLengthTableTruncatedPayload(t *testing.T) {
+	stream := buildStream(lengthTable(payloads(2, 20)...))
+	stream = stream[:len(stream)-5]
+	_, err := Probe(bytes.NewReader(stream))
+	if !errors.Is(err, ErrTruncatedStream) {
+		t.Fatalf("Probe = %v, want ErrTruncatedStream", err)
+	}
+}
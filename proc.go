@@ -0,0 +1,73 @@
+package silk
+
+import (
+	"fmt"
+	"strings"
+)
+
+// procAliases 每个逻辑 proc 的候选导出名
+// 不同编译的 dllsilk.dll 导出名大小写不一致, 32 位 stdcall 版本还带有 _Name@N 修饰
+var procAliases = map[string][]string{
+	"CreateDecoder":      {"CreateDecoder", "createDecoder", "_CreateDecoder@0", "_createDecoder@0"},
+	"CloseDecoder":       {"CloseDecoder", "closeDecoder", "_CloseDecoder@4", "_closeDecoder@4"},
+	"setSampleRate":      {"setSampleRate", "SetSampleRate", "_setSampleRate@8", "_SetSampleRate@8"},
+	"setFramesPerPacket": {"setFramesPerPacket", "SetFramesPerPacket", "_setFramesPerPacket@8", "_SetFramesPerPacket@8"},
+	"Decode":             {"Decode", "decode", "_Decode@20", "_decode@20"},
+	"getSupportedRates":  {"getSupportedRates", "GetSupportedRates", "_getSupportedRates@8"},
+	"getSampleRate":      {"getSampleRate", "GetSampleRate", "_getSampleRate@4"},
+}
+
+// proc 按候选名依次查找逻辑 proc, 并缓存第一个解析成功的结果
+// 找不到的结果同样缓存, 可选的 proc(如 getSampleRate)不会在每次解码时重新查找
+func (s *silk) proc(name string) (*dllProc, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if p, ok := s.procs[name]; ok {
+		return p, nil
+	}
+	if err, ok := s.procErrs[name]; ok {
+		return nil, err
+	}
+	if s.dll == nil {
+		return nil, fmt.Errorf("silk dll not loaded: %w", s.err)
+	}
+	names := procAliases[name]
+	if len(names) == 0 {
+		names = []string{name}
+	}
+	for _, n := range names {
+		p, err := s.dll.FindProc(n)
+		if err != nil {
+			continue
+		}
+		if s.procs == nil {
+			s.procs = make(map[string]*dllProc)
+		}
+		s.procs[name] = p
+		return p, nil
+	}
+	err := fmt.Errorf("failed to find proc %s in %s, tried: %s", name, s.dll.Name, strings.Join(names, ", "))
+	if s.procErrs == nil {
+		s.procErrs = make(map[string]error)
+	}
+	s.procErrs[name] = err
+	return nil, err
+}
+
+// Warmup 解析全部 proc 并创建、关闭一次解码器, 让首次解码不再承担初始化的耗时
+// 服务启动时调用, dll 不可用时返回错误以便尽早失败
+func (s *silk) Warmup() error {
+	if s.native == nil {
+		for _, name := range []string{"CreateDecoder", "CloseDecoder", "setSampleRate", "setFramesPerPacket", "Decode"} {
+			if _, err := s.proc(name); err != nil {
+				return err
+			}
+		}
+	}
+	lib := s.lib()
+	handle, err := s.openDecoder(lib, DefaultDecodeOptions())
+	if err != nil {
+		return err
+	}
+	return lib.closeDecoder(handle)
+}
@@ -0,0 +1,42 @@
+package silk
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestWarmup(t *testing.T) {
+	f := newFakeNative()
+	if err := fakeDecoder(f).Warmup(); err != nil {
+		t.Fatal(err)
+	}
+	f.checkLeaks(t)
+	if f.created != 1 || len(f.rates) != 1 {
+		t.Fatalf("created %d decoders, set %d sample rates, want 1 each", f.created, len(f.rates))
+	}
+}
+
+func TestWarmupCachesProcs(t *testing.T) {
+	s := NewSilkDecoderWithDLL(filepath.Join("bin", "dllsilk.dll"))
+	if s.err != nil {
+		t.Skipf("dll not available: %v", s.err)
+	}
+	if err := s.Warmup(); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"CreateDecoder", "CloseDecoder", "setSampleRate", "setFramesPerPacket", "Decode"} {
+		if s.procs[name] == nil {
+			t.Errorf("proc %s not cached after Warmup", name)
+		}
+	}
+}
+
+func TestWarmupMissingDLL(t *testing.T) {
+	s := NewSilkDecoderWithDLL(filepath.Join(t.TempDir(), "missing.dll"))
+	if err := s.Warmup(); err == nil {
+		t.Fatal("Warmup succeeded without a dll")
+	}
+	if len(s.procs) != 0 {
+		t.Fatalf("resolved procs %v without a dll", s.procs)
+	}
+}
@@ -0,0 +1,75 @@
+package silk
+
+import (
+	"fmt"
+	"unsafe"
+)
+
+// sdkSampleRates SKP_Silk_SDK_Decode 支持的输出(API)采样率
+var sdkSampleRates = []int{8000, 12000, 16000, 24000, 32000, 44100, 48000}
+
+// SupportedRates 返回当前 dll 支持的输出采样率, 结果会被缓存
+// dll 导出 getSupportedRates(int *rates, int max) 时以其结果为准, 否则返回 SILK SDK 支持的采样率;
+// dllsilk.dll 的 setSampleRate 没有返回值, 无法逐个试探, 此时返回的只是参考值, 解码时不据此拒绝
+func (s *silk) SupportedRates() ([]int, error) {
+	rates, err := s.supportedRates()
+	return append([]int(nil), rates...), err
+}
+
+func (s *silk) supportedRates() ([]int, error) {
+	s.ratesOnce.Do(func() {
+		s.rates, s.ratesProbed, s.ratesErr = s.probeRates()
+	})
+	return s.rates, s.ratesErr
+}
+
+// probeRates 查询 dll 支持的采样率, probed 表示结果来自 dll 而不是 sdkSampleRates
+func (s *silk) probeRates() (rates []int, probed bool, err error) {
+	if s.native != nil {
+		return sdkSampleRates, false, nil
+	}
+	if s.dll == nil {
+		return nil, false, fmt.Errorf("silk dll not loaded: %w", s.err)
+	}
+	f, err := s.proc("getSupportedRates")
+	if err != nil {
+		return sdkSampleRates, false, nil
+	}
+	var buf [16]int32
+	ret, _, err := f.Call(uintptr(unsafe.Pointer(&buf[0])), uintptr(len(buf)))
+	if callFailed(err) {
+		return nil, false, err
+	}
+	n := int(int32(ret))
+	if n <= 0 || n > len(buf) {
+		return nil, false, fmt.Errorf("invalid getSupportedRates result: %d", n)
+	}
+	rates = make([]int, n)
+	for i := range rates {
+		rates[i] = int(buf[i])
+	}
+	return rates, true, nil
+}
+
+// checkSampleRate 确认 dll 支持 rate
+// 只有 dll 通过 getSupportedRates 报告了支持的采样率时才返回 ErrUnsupportedSampleRate,
+// 否则不在 SILK SDK 列表中的采样率只记录警告, 仍交给 dll 处理
+func (s *silk) checkSampleRate(rate int) error {
+	if rate <= 0 {
+		return fmt.Errorf("%w: %d", ErrUnsupportedSampleRate, rate)
+	}
+	rates, err := s.supportedRates()
+	if err != nil {
+		return err
+	}
+	for _, r := range rates {
+		if r == rate {
+			return nil
+		}
+	}
+	if !s.ratesProbed {
+		logger.Warn("sample rate %d is not in the silk sdk rates %v, pass it to the dll anyway", rate, rates)
+		return nil
+	}
+	return fmt.Errorf("%w: %d, supported: %v", ErrUnsupportedSampleRate, rate, rates)
+}
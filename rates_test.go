@@ -0,0 +1,148 @@
+package silk
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestEffectiveSampleRate(t *testing.T) {
+	lib := rateFake{newFakeNative(), 24000}
+	stream := withFooter(buildStream(nil, payloads(50, 30)...))
+	pcm, info, err := fakeDecoder(lib).DecodeWithInfo(bytes.NewReader(stream), DecodeOptions{SampleRate: 16000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	lib.checkLeaks(t)
+	if info.SampleRate != 16000 || info.EffectiveSampleRate != 24000 {
+		t.Fatalf("SampleRate = %d, EffectiveSampleRate = %d, want 16000 and 24000", info.SampleRate, info.EffectiveSampleRate)
+	}
+	if len(pcm) != 50*960 {
+		t.Fatalf("decoded %d bytes, want %d", len(pcm), 50*960)
+	}
+	// 没有 getSampleRate 时等于请求的采样率
+	_, info, err = decodeFake(t, stream, DecodeOptions{SampleRate: 16000})
+	if err != nil || info.EffectiveSampleRate != 16000 {
+		t.Fatalf("without a getter: EffectiveSampleRate = %d, %v", info.EffectiveSampleRate, err)
+	}
+}
+
+func TestEffectiveSampleRateInWav(t *testing.T) {
+	stream := withFooter(buildStream(nil, payloads(50, 30)...))
+	check := func(name string, data []byte) {
+		t.Helper()
+		f, pcm := parseWav(t, data)
+		if f.sampleRate != 24000 || f.byteRate != 48000 {
+			t.Errorf("%s: header says %d Hz, %d B/s, want 24000 Hz", name, f.sampleRate, f.byteRate)
+		}
+		if len(pcm) != 50*960 {
+			t.Errorf("%s: %d bytes of pcm, want %d", name, len(pcm), 50*960)
+		}
+	}
+	useFake(t, rateFake{newFakeNative(), 24000})
+
+	data, err := SilkToWavBytes(bytes.NewReader(stream), WavOptions{SampleRate: 16000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	check("SilkToWavBytes", data)
+
+	r, err := SilkToWav(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ = io.ReadAll(r)
+	check("SilkToWav", data)
+
+	ws := &memWriteSeeker{}
+	if err = EncodeWavTo(ws, bytes.NewReader(stream), WavOptions{SampleRate: 16000}); err != nil {
+		t.Fatal(err)
+	}
+	check("EncodeWavTo", ws.buf)
+
+	r, err = SilkToFormat(bytes.NewReader(stream), "wav")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ = io.ReadAll(r)
+	check("SilkToFormat", data)
+}
+
+func TestEffectiveSampleRateDuration(t *testing.T) {
+	useFake(t, rateFake{newFakeNative(), 24000})
+	stream := withFooter(buildStream(nil, payloads(50, 30)...))
+	// 按实际的采样率计算时长, 1 秒的音频不会被当作 1.5 秒补齐到 2 秒
+	pcm, err := newDecoder().DecodeWithOptions(bytes.NewReader(stream), DecodeOptions{SampleRate: 16000, PadToSeconds: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pcm) != 24000*2 {
+		t.Fatalf("padded to %d bytes, want %d", len(pcm), 24000*2)
+	}
+	_, err = newDecoder().DecodeWithOptions(bytes.NewReader(stream), DecodeOptions{SampleRate: 16000, ExpectedDuration: time.Second})
+	if err != nil {
+		t.Fatalf("ExpectedDuration of 1s: %v", err)
+	}
+}
+
+func TestEffectiveSampleRateStrict(t *testing.T) {
+	lib := rateFake{newFakeNative(), 24000}
+	stream := withFooter(buildStream(nil, payloads(2, 30)...))
+	_, err := fakeDecoder(lib).DecodeWithOptions(bytes.NewReader(stream), DecodeOptions{SampleRate: 16000, StrictMode: true})
+	if !errors.Is(err, ErrSampleRateOverridden) {
+		t.Fatalf("err = %v, want ErrSampleRateOverridden", err)
+	}
+	lib.checkLeaks(t)
+}
+
+func TestSupportedRates(t *testing.T) {
+	s := fakeDecoder(newFakeNative())
+	rates, err := s.SupportedRates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []int{8000, 12000, 16000, 24000, 32000, 44100, 48000}
+	if len(rates) != len(want) {
+		t.Fatalf("SupportedRates = %v, want %v", rates, want)
+	}
+	for i := range want {
+		if rates[i] != want[i] {
+			t.Fatalf("SupportedRates = %v, want %v", rates, want)
+		}
+	}
+	for _, rate := range rates {
+		if err := s.checkSampleRate(rate); err != nil {
+			t.Errorf("checkSampleRate(%d): %v", rate, err)
+		}
+	}
+	// 返回的是副本, 修改不影响之后的结果
+	rates[0] = 1
+	if again, _ := s.SupportedRates(); again[0] != 8000 {
+		t.Fatalf("SupportedRates = %v after modifying the previous result", again)
+	}
+
+	for _, rate := range []int{0, -8000} {
+		if err := s.checkSampleRate(rate); !errors.Is(err, ErrUnsupportedSampleRate) {
+			t.Errorf("checkSampleRate(%d) = %v, want ErrUnsupportedSampleRate", rate, err)
+		}
+	}
+	// 结果不是 dll 报告的, 列表外的采样率只警告
+	l := captureLogs(func() {
+		if err := s.checkSampleRate(11025); err != nil {
+			t.Errorf("checkSampleRate(11025): %v", err)
+		}
+	})
+	if !l.contains("11025") {
+		t.Errorf("no warning for 11025: %q", l.lines)
+	}
+}
+
+func TestSupportedRatesWithoutDLL(t *testing.T) {
+	s := NewSilkDecoderWithDLL(filepath.Join(t.TempDir(), "missing.dll"))
+	if rates, err := s.SupportedRates(); err == nil {
+		t.Fatalf("SupportedRates = %v without a dll", rates)
+	}
+}
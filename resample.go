@@ -0,0 +1,91 @@
+package silk
+
+import (
+	"fmt"
+	"math"
+)
+
+// Resampler 采样率转换接口, 可接入 SoX 等更高质量的实现
+type Resampler interface {
+	Resample(in []int16, fromRate, toRate int) ([]int16, error)
+}
+
+// linearResampler 内置的线性插值重采样, 不做抗混叠滤波
+type linearResampler struct{}
+
+func (linearResampler) Resample(in []int16, fromRate, toRate int) ([]int16, error) {
+	if fromRate <= 0 || toRate <= 0 {
+		return nil, fmt.Errorf("invalid resample rate %d -> %d", fromRate, toRate)
+	}
+	if fromRate == toRate || len(in) == 0 {
+		return append([]int16(nil), in...), nil
+	}
+	out := make([]int16, int64(len(in))*int64(toRate)/int64(fromRate))
+	step := float64(fromRate) / float64(toRate)
+	for i := range out {
+		pos := float64(i) * step
+		j := int(pos)
+		a, b := float64(in[j]), float64(in[j])
+		if j+1 < len(in) {
+			b = float64(in[j+1])
+		}
+		out[i] = clip16(a + (b-a)*(pos-float64(j)))
+	}
+	return out, nil
+}
+
+// ResampleQuality 内置重采样的质量
+type ResampleQuality int
+
+const (
+	// ResampleFast 线性插值, 降采样时高于新奈奎斯特频率的成分会混叠
+	ResampleFast ResampleQuality = iota
+	// ResampleHigh 降采样前先用 FIR 低通滤波抗混叠, 用于语音识别等对清晰度敏感的场景; 升采样与 ResampleFast 相同
+	ResampleHigh
+)
+
+// firZeroCrossings 低通滤波器每侧包含的 sinc 过零点数, 越大过渡带越窄
+const firZeroCrossings = 16
+
+// firResampler 降采样时先做加 Blackman 窗的 sinc 低通滤波再线性插值
+type firResampler struct{}
+
+func (firResampler) Resample(in []int16, fromRate, toRate int) ([]int16, error) {
+	if fromRate <= 0 || toRate <= 0 {
+		return nil, fmt.Errorf("invalid resample rate %d -> %d", fromRate, toRate)
+	}
+	if toRate < fromRate && len(in) > 0 {
+		// 截止频率略低于新的奈奎斯特频率, 给过渡带留出余量
+		in = lowPass(in, 0.45*float64(toRate)/float64(fromRate))
+	}
+	return linearResampler{}.Resample(in, fromRate, toRate)
+}
+
+// lowPass 以 cutoff(相对采样率, 0~0.5)对 in 做线性相位 FIR 低通滤波, 返回同样长度的结果
+func lowPass(in []int16, cutoff float64) []int16 {
+	half := int(math.Ceil(firZeroCrossings / (2 * cutoff)))
+	taps := make([]float64, 2*half+1)
+	var sum float64
+	for i := range taps {
+		x := float64(i - half)
+		v := 2 * cutoff
+		if x != 0 {
+			v = math.Sin(2*math.Pi*cutoff*x) / (math.Pi * x)
+		}
+		w := 2 * math.Pi * float64(i) / float64(len(taps)-1)
+		v *= 0.42 - 0.5*math.Cos(w) + 0.08*math.Cos(2*w)
+		taps[i] = v
+		sum += v
+	}
+	out := make([]int16, len(in))
+	for i := range in {
+		var acc float64
+		for k, t := range taps {
+			if j := i + k - half; j >= 0 && j < len(in) {
+				acc += t * float64(in[j])
+			}
+		}
+		out[i] = clip16(acc / sum) // 归一化使直流增益为 1
+	}
+	return out
+}
@@ -0,0 +1,125 @@
+package silk
+
+import (
+	"bytes"
+	"errors"
+	"math"
+	"testing"
+)
+
+// toneRMS 返回 s 去掉两端 edge 个采样(滤波器的过渡部分)后的 RMS
+func toneRMS(s []int16, edge int) float64 {
+	_, rms := meanRMS(s[edge : len(s)-edge])
+	return rms
+}
+
+func TestResampleAttenuatesAboveNyquist(t *testing.T) {
+	const from, to, amp = 24000, 8000, 10000.0
+	inRMS := amp / math.Sqrt2
+	for _, tt := range []struct {
+		freq    float64
+		fast    func(db float64) bool
+		quality func(db float64) bool
+	}{
+		// 6 kHz 高于 8 kHz 的奈奎斯特频率 4 kHz: 线性插值混叠到 2 kHz, FIR 应衰减 40 dB 以上
+		{6000, func(db float64) bool { return db > -20 }, func(db float64) bool { return db < -40 }},
+		{5000, func(db float64) bool { return db > -20 }, func(db float64) bool { return db < -40 }},
+		// 1 kHz 在通带内, 两者都应基本保持电平
+		{1000, func(db float64) bool { return db > -1 }, func(db float64) bool { return math.Abs(db) < 0.5 }},
+	} {
+		in := sineSamples(from, tt.freq, amp, from) // 1 秒
+		for _, r := range []struct {
+			name string
+			rs   Resampler
+			ok   func(float64) bool
+		}{
+			{"fast", linearResampler{}, tt.fast},
+			{"quality", firResampler{}, tt.quality},
+		} {
+			out, err := r.rs.Resample(in, from, to)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(out) != to {
+				t.Fatalf("%s: %d samples, want %d", r.name, len(out), to)
+			}
+			db := 20 * math.Log10(toneRMS(out, to/20)/inRMS)
+			if !r.ok(db) {
+				t.Errorf("%s: %.0f Hz tone at %.1f dB after %d -> %d", r.name, tt.freq, db, from, to)
+			}
+		}
+	}
+}
+
+func TestFirResamplerUpsampleMatchesLinear(t *testing.T) {
+	in := sineSamples(800, 1000, 8000, 8000)
+	fast, _ := linearResampler{}.Resample(in, 8000, 16000)
+	quality, _ := firResampler{}.Resample(in, 8000, 16000)
+	if !equalSamples(fast, quality) {
+		t.Fatal("upsampling with ResampleHigh differs from ResampleFast")
+	}
+}
+
+func TestDecodeResampleQuality(t *testing.T) {
+	tone := sineSamples(24000, 6000, 10000, 24000)
+	stream := buildStream(nil, payloads(50, 4)...) // 50 帧 @24kHz = 1 秒
+	levels := map[ResampleQuality]float64{}
+	for _, q := range []ResampleQuality{ResampleFast, ResampleHigh} {
+		f := samplesNative(tone)
+		pcm, err := fakeDecoder(f).DecodeWithOptions(bytes.NewReader(stream),
+			DecodeOptions{SampleRate: 24000, ResampleTo: 8000, ResampleQuality: q})
+		f.checkLeaks(t)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(pcm) != 8000*2 {
+			t.Fatalf("quality %d: %d bytes, want %d", q, len(pcm), 8000*2)
+		}
+		levels[q] = toneRMS(bytesToSamples(pcm), 400)
+	}
+	if db := 20 * math.Log10(levels[ResampleHigh]/levels[ResampleFast]); db > -30 {
+		t.Fatalf("ResampleHigh vs ResampleFast on a 6 kHz tone: %.1f dB, want below -30", db)
+	}
+}
+
+// recordResampler 记录调用参数, 输出固定的 out
+type recordResampler struct {
+	calls          int
+	in             int
+	fromRate, rate int
+	out            []int16
+	err            error
+}
+
+func (r *recordResampler) Resample(in []int16, fromRate, toRate int) ([]int16, error) {
+	r.calls++
+	r.in, r.fromRate, r.rate = len(in), fromRate, toRate
+	return r.out, r.err
+}
+
+func TestDecodeCustomResampler(t *testing.T) {
+	stream := withFooter(buildStream(nil, payloads(3, 4)...))
+	rs := &recordResampler{out: []int16{1, -2, 3}}
+	pcm, _, err := decodeFake(t, stream, DecodeOptions{ResampleTo: 8000, Resampler: rs, ResampleQuality: ResampleHigh})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rs.calls != 1 || rs.in != 3*320 || rs.fromRate != 16000 || rs.rate != 8000 {
+		t.Fatalf("Resample called %d times with %d samples %d -> %d", rs.calls, rs.in, rs.fromRate, rs.rate)
+	}
+	// 输出直接使用自定义实现的结果, ResampleQuality 被忽略
+	if !bytes.Equal(pcm, samplesToBytes(rs.out)) {
+		t.Fatalf("pcm %v, want the resampler output %v", bytesToSamples(pcm), rs.out)
+	}
+
+	rs = &recordResampler{err: errors.New("resample failed")}
+	if _, _, err = decodeFake(t, stream, DecodeOptions{ResampleTo: 8000, Resampler: rs}); !errors.Is(err, rs.err) {
+		t.Fatalf("err = %v, want the resampler error", err)
+	}
+
+	// 不需要重采样时不调用
+	rs = &recordResampler{}
+	if _, _, err = decodeFake(t, stream, DecodeOptions{ResampleTo: 16000, Resampler: rs}); err != nil || rs.calls != 0 {
+		t.Fatalf("same rate: %d calls, err = %v", rs.calls, err)
+	}
+}
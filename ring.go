@@ -0,0 +1,124 @@
+package silk
+
+import (
+	"io"
+	"sync"
+)
+
+// RingPipe 容量固定的环形缓冲, 生产者写入 silk 数据, 解码器作为 io.Reader 读取
+// 与 io.Pipe 不同, 写入的数据先进入缓冲区, 缓冲区满时 Write 阻塞, 空时 Read 阻塞,
+// 用于实时接收并解码, 内存占用不超过 size. 解码方出错退出时应调用 Close, 唤醒阻塞在 Write 上的生产者
+type RingPipe struct {
+	mu         sync.Mutex
+	cond       *sync.Cond
+	buf        []byte
+	start      int // 第一个未读字节的位置
+	n          int // 未读字节数
+	closed     bool
+	closeErr   error
+	readClosed bool // 读端已关闭, 之后的 Write 返回 io.ErrClosedPipe
+}
+
+// NewRingPipe 创建容量为 size 字节的 RingPipe
+func NewRingPipe(size int) *RingPipe {
+	if size <= 0 {
+		size = defaultReadBufferSize
+	}
+	p := &RingPipe{buf: make([]byte, size)}
+	p.cond = sync.NewCond(&p.mu)
+	return p
+}
+
+// Write 写入 b, 缓冲区满时阻塞直到被读出; CloseWrite 之后返回 ErrRingClosed, 读端 Close 之后返回 io.ErrClosedPipe
+func (p *RingPipe) Write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	var written int
+	for len(b) > 0 {
+		for p.n == len(p.buf) && !p.closed && !p.readClosed {
+			p.cond.Wait()
+		}
+		if p.readClosed {
+			return written, io.ErrClosedPipe
+		}
+		if p.closed {
+			return written, ErrRingClosed
+		}
+		end := (p.start + p.n) % len(p.buf)
+		limit := len(p.buf)
+		if end < p.start {
+			limit = p.start
+		}
+		if space := len(p.buf) - p.n; limit-end > space {
+			limit = end + space
+		}
+		c := copy(p.buf[end:limit], b)
+		p.n += c
+		written += c
+		b = b[c:]
+		p.cond.Broadcast()
+	}
+	return written, nil
+}
+
+// Read 读出缓冲区中的数据, 为空时阻塞; CloseWrite 之后读完剩余数据返回 io.EOF
+func (p *RingPipe) Read(b []byte) (int, error) {
+	if len(b) == 0 {
+		return 0, nil
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.readClosed {
+		return 0, io.ErrClosedPipe
+	}
+	for p.n == 0 && !p.closed {
+		p.cond.Wait()
+	}
+	if p.n == 0 {
+		return 0, p.closeErr
+	}
+	limit := p.start + p.n
+	if limit > len(p.buf) {
+		limit = len(p.buf)
+	}
+	c := copy(b, p.buf[p.start:limit])
+	p.start = (p.start + c) % len(p.buf)
+	p.n -= c
+	p.cond.Broadcast()
+	return c, nil
+}
+
+// CloseWrite 表示数据已经写完, 读端读完剩余数据后得到 io.EOF
+func (p *RingPipe) CloseWrite() error {
+	return p.CloseWithError(nil)
+}
+
+// CloseWithError 同 CloseWrite, 读端读完剩余数据后得到 err(为 nil 时为 io.EOF)
+func (p *RingPipe) CloseWithError(err error) error {
+	if err == nil {
+		err = io.EOF
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.closed {
+		p.closed = true
+		p.closeErr = err
+		p.cond.Broadcast()
+	}
+	return nil
+}
+
+// Close 同 CloseRead, 使 RingPipe 可以作为 io.ReadCloser 交给消费方
+func (p *RingPipe) Close() error {
+	return p.CloseRead()
+}
+
+// CloseRead 关闭读端, 丢弃未读的数据; 阻塞在 Write 上的生产者和之后的 Write、Read 都返回 io.ErrClosedPipe
+func (p *RingPipe) CloseRead() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.readClosed = true
+	p.n = 0
+	p.cond.Broadcast()
+	return nil
+}
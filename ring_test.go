@@ -0,0 +1,147 @@
+package silk
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// writeAsync 在后台写入 b, 返回接收写入结果的 channel
+func writeAsync(p *RingPipe, b []byte) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		_, err := p.Write(b)
+		done <- err
+	}()
+	return done
+}
+
+func TestRingPipeBackpressure(t *testing.T) {
+	p := NewRingPipe(4)
+	data := []byte("0123456789")
+	done := writeAsync(p, data)
+	select {
+	case err := <-done:
+		t.Fatalf("Write of %d bytes into a 4 byte ring returned early: %v", len(data), err)
+	case <-time.After(50 * time.Millisecond):
+	}
+	got := make([]byte, 0, len(data))
+	buf := make([]byte, 3)
+	for len(got) < len(data) {
+		n, err := p.Read(buf)
+		if err != nil {
+			t.Fatalf("Read: %v", err)
+		}
+		if n > 4 {
+			t.Fatalf("Read returned %d bytes, more than the ring size", n)
+		}
+		got = append(got, buf[:n]...)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if !bytes.Equal(got, data) {
+		t.Fatalf("read %q, want %q", got, data)
+	}
+}
+
+func TestRingPipeReadBlocksUntilWrite(t *testing.T) {
+	p := NewRingPipe(8)
+	read := make(chan []byte, 1)
+	go func() {
+		buf := make([]byte, 8)
+		n, _ := p.Read(buf)
+		read <- buf[:n]
+	}()
+	select {
+	case b := <-read:
+		t.Fatalf("Read on an empty ring returned %q", b)
+	case <-time.After(50 * time.Millisecond):
+	}
+	if _, err := p.Write([]byte("ab")); err != nil {
+		t.Fatal(err)
+	}
+	if b := <-read; string(b) != "ab" {
+		t.Fatalf("read %q, want %q", b, "ab")
+	}
+}
+
+func TestRingPipeCloseWrite(t *testing.T) {
+	p := NewRingPipe(8)
+	if _, err := p.Write([]byte("abc")); err != nil {
+		t.Fatal(err)
+	}
+	p.CloseWrite()
+	if _, err := p.Write([]byte("d")); !errors.Is(err, ErrRingClosed) {
+		t.Fatalf("Write after CloseWrite = %v, want ErrRingClosed", err)
+	}
+	got, err := io.ReadAll(p)
+	if err != nil || string(got) != "abc" {
+		t.Fatalf("ReadAll = %q, %v; want remaining data and EOF", got, err)
+	}
+}
+
+func TestRingPipeCloseWithError(t *testing.T) {
+	p := NewRingPipe(8)
+	want := errors.New("capture failed")
+	p.CloseWithError(want)
+	if _, err := p.Read(make([]byte, 1)); err != want {
+		t.Fatalf("Read = %v, want %v", err, want)
+	}
+}
+
+func TestRingPipeCloseUnblocksWriter(t *testing.T) {
+	p := NewRingPipe(4)
+	done := writeAsync(p, []byte("0123456789"))
+	time.Sleep(20 * time.Millisecond)
+	p.Close()
+	select {
+	case err := <-done:
+		if !errors.Is(err, io.ErrClosedPipe) {
+			t.Fatalf("blocked Write = %v, want io.ErrClosedPipe", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Close did not wake the blocked writer")
+	}
+	if _, err := p.Write([]byte("x")); !errors.Is(err, io.ErrClosedPipe) {
+		t.Fatalf("Write after Close = %v, want io.ErrClosedPipe", err)
+	}
+	if _, err := p.Read(make([]byte, 1)); !errors.Is(err, io.ErrClosedPipe) {
+		t.Fatalf("Read after Close = %v, want io.ErrClosedPipe", err)
+	}
+}
+
+func TestRingPipeDecode(t *testing.T) {
+	// 缓冲区比整个流小得多, 生产者必须等解码器消费后才能继续写入
+	stream := withFooter(buildStream(nil, payloads(100, 30)...))
+	p := NewRingPipe(64)
+	done := make(chan error, 1)
+	go func() {
+		for rest := stream; len(rest) > 0; {
+			n := 17
+			if n > len(rest) {
+				n = len(rest)
+			}
+			if _, err := p.Write(rest[:n]); err != nil {
+				done <- err
+				return
+			}
+			rest = rest[n:]
+		}
+		done <- p.CloseWrite()
+	}()
+	f := newFakeNative()
+	pcm, err := fakeDecoder(f).DecodeWithOptions(p, DecodeOptions{ReadBufferSize: 16})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = <-done; err != nil {
+		t.Fatal(err)
+	}
+	f.checkLeaks(t)
+	if got := firstSamples(pcm, 640); !equalSamples(got, wantFrames(100)) {
+		t.Fatalf("decoded %d frames, want 100", len(got))
+	}
+}
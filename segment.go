@@ -0,0 +1,90 @@
+package silk
+
+import (
+	"io"
+	"math"
+)
+
+// segmentWindowMs 判断静音的窗口长度
+const segmentWindowMs = 10
+
+// SegmentOptions DecodeSegments 的参数
+type SegmentOptions struct {
+	Decode           DecodeOptions // 解码参数
+	SilenceThreshold int16         // 窗口 RMS 低于该值视为静音, 为 0 时使用 500(约 -36dBFS)
+	MinSilenceMs     int           // 持续至少这么长的静音才切分, 为 0 时使用 300
+	MinSegmentMs     int           // 短于该长度的片段被丢弃, 为 0 时使用 200
+}
+
+func (o SegmentOptions) withDefaults() SegmentOptions {
+	o.Decode = o.Decode.withDefaults()
+	if o.SilenceThreshold <= 0 {
+		o.SilenceThreshold = 500
+	}
+	if o.MinSilenceMs <= 0 {
+		o.MinSilenceMs = 300
+	}
+	if o.MinSegmentMs <= 0 {
+		o.MinSegmentMs = 200
+	}
+	return o
+}
+
+// Segment 一段有声音的片段, 时间相对于音频开头
+type Segment struct {
+	StartMs, EndMs int
+	PCM            []byte // 该片段的 pcm, 与完整解码结果共用内存
+}
+
+// DecodeSegments 解码 src 并按静音切分为片段, 用于语音识别前的预处理
+// 以 10ms 为窗口计算 RMS, 连续 MinSilenceMs 的静音窗口作为分界, 片段不包含两端的静音
+func DecodeSegments(src io.Reader, opts SegmentOptions) ([]Segment, error) {
+	opts = opts.withDefaults()
+	if err := opts.Decode.requireLinear(); err != nil {
+		return nil, err
+	}
+	pcm, info, err := newDecoder().DecodeWithInfo(src, opts.Decode)
+	if err != nil {
+		return nil, err
+	}
+	samples := bytesToSamples(pcm)
+	rate := opts.Decode.decodedRate(info)
+	window := rate * segmentWindowMs / 1000
+	threshold := float64(opts.SilenceThreshold)
+	minSilence := opts.MinSilenceMs / segmentWindowMs
+	var segments []Segment
+	start, end, silent := -1, 0, 0 // start/end 为当前片段的窗口范围, silent 为之后连续静音的窗口数
+	emit := func() {
+		if start >= 0 && (end-start)*segmentWindowMs >= opts.MinSegmentMs {
+			segments = append(segments, Segment{
+				StartMs: start * segmentWindowMs,
+				EndMs:   end * segmentWindowMs,
+				PCM:     pcm[start*window*2 : end*window*2],
+			})
+		}
+		start = -1
+	}
+	for i := 0; (i+1)*window <= len(samples); i++ {
+		if windowRMS(samples[i*window:(i+1)*window]) >= threshold {
+			if start < 0 {
+				start = i
+			}
+			end, silent = i+1, 0
+			continue
+		}
+		if silent++; silent >= minSilence {
+			emit()
+		}
+	}
+	emit()
+	return segments, nil
+}
+
+// windowRMS 返回采样的均方根
+func windowRMS(samples []int16) float64 {
+	var sum float64
+	for _, v := range samples {
+		sum += float64(v) * float64(v)
+	}
+	return math.Sqrt(sum / float64(len(samples)))
+}
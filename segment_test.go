@@ -0,0 +1,79 @@
+package silk
+
+import (
+	"bytes"
+	"testing"
+)
+
+// frameLevels 按 (帧数, 采样值) 依次展开为每帧的采样值
+func frameLevels(runs ...[2]int) []int16 {
+	var levels []int16
+	for _, run := range runs {
+		for i := 0; i < run[0]; i++ {
+			levels = append(levels, int16(run[1]))
+		}
+	}
+	return levels
+}
+
+func TestDecodeSegments(t *testing.T) {
+	levels := frameLevels(
+		[2]int{10, 0},    // 0~200ms 静音
+		[2]int{25, 3000}, // 200~700ms
+		[2]int{20, 0},    // 400ms 静音, 切分
+		[2]int{5, 3000},  // 100ms, 短于 MinSegmentMs 被丢弃
+		[2]int{20, 0},
+		[2]int{15, 3000}, // 1600~1900ms
+		[2]int{5, 0},     // 100ms 静音, 不足 MinSilenceMs 不切分
+		[2]int{15, -3000},
+		[2]int{10, 0},
+	)
+	f := levelsNative(levels...)
+	useFake(t, f)
+	stream := withFooter(buildStream(nil, payloads(len(levels), 30)...))
+	segments, err := DecodeSegments(bytes.NewReader(stream), SegmentOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.checkLeaks(t)
+	want := [][2]int{{200, 700}, {1600, 2300}}
+	if len(segments) != len(want) {
+		t.Fatalf("got %d segments %v, want %v", len(segments), segmentRanges(segments), want)
+	}
+	for i, seg := range segments {
+		if seg.StartMs != want[i][0] || seg.EndMs != want[i][1] {
+			t.Errorf("segment %d = %d~%dms, want %d~%dms", i, seg.StartMs, seg.EndMs, want[i][0], want[i][1])
+		}
+		if wantLen := (seg.EndMs - seg.StartMs) * 16 * 2; len(seg.PCM) != wantLen {
+			t.Errorf("segment %d has %d bytes of pcm, want %d", i, len(seg.PCM), wantLen)
+		}
+	}
+	if s := bytesToSamples(segments[0].PCM); s[0] != 3000 || s[len(s)-1] != 3000 {
+		t.Errorf("segment 0 includes silence at its edges")
+	}
+}
+
+func TestDecodeSegmentsAllSilence(t *testing.T) {
+	useFake(t, levelsNative(100)) // 低于默认阈值 500
+	stream := withFooter(buildStream(nil, payloads(50, 30)...))
+	segments, err := DecodeSegments(bytes.NewReader(stream), SegmentOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(segments) != 0 {
+		t.Fatalf("got segments %v from silence", segmentRanges(segments))
+	}
+	// 阈值调低之后整段都是一个片段
+	segments, err = DecodeSegments(bytes.NewReader(stream), SegmentOptions{SilenceThreshold: 50})
+	if err != nil || len(segments) != 1 || segments[0].StartMs != 0 || segments[0].EndMs != 1000 {
+		t.Fatalf("threshold 50: %v, %v", segmentRanges(segments), err)
+	}
+}
+
+func segmentRanges(segments []Segment) [][2]int {
+	out := make([][2]int, len(segments))
+	for i, s := range segments {
+		out[i] = [2]int{s.StartMs, s.EndMs}
+	}
+	return out
+}
@@ -0,0 +1,76 @@
+package silk
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// buildStream 生成测试用的 silk 流: 文件头 + ext + 每帧小端序长度前缀和负载
+func buildStream(ext []byte, frames ...[]byte) []byte {
+	return buildStreamOrder(binary.LittleEndian, ext, frames...)
+}
+
+// buildStreamOrder 同 buildStream, 长度前缀使用 order
+func buildStreamOrder(order binary.ByteOrder, ext []byte, frames ...[]byte) []byte {
+	out := append([]byte(Header), ext...)
+	for _, f := range frames {
+		out = append(out, 0, 0)
+		order.PutUint16(out[len(out)-2:], uint16(len(f)))
+		out = append(out, f...)
+	}
+	return out
+}
+
+// withFooter 在流的末尾加上 footer(长度 -1)
+func withFooter(stream []byte) []byte {
+	return append(stream, 0xFF, 0xFF)
+}
+
+// payloads 生成 n 个长度为 size 的帧负载, 内容为帧序号
+func payloads(n, size int) [][]byte {
+	frames := make([][]byte, n)
+	for i := range frames {
+		frames[i] = []byte(strings.Repeat(string(rune('a'+i%26)), size))
+	}
+	return frames
+}
+
+// recordLogger 记录日志的 Logger
+type recordLogger struct {
+	mu    sync.Mutex
+	lines []string
+}
+
+func (l *recordLogger) log(level, format string, args ...interface{}) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.lines = append(l.lines, level+" "+fmt.Sprintf(format, args...))
+}
+
+func (l *recordLogger) Debug(format string, args ...interface{}) { l.log("DEBUG", format, args...) }
+func (l *recordLogger) Info(format string, args ...interface{})  { l.log("INFO", format, args...) }
+func (l *recordLogger) Warn(format string, args ...interface{})  { l.log("WARN", format, args...) }
+
+// contains 返回是否有包含 substr 的日志
+func (l *recordLogger) contains(substr string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, line := range l.lines {
+		if strings.Contains(line, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+// captureLogs 在 fn 执行期间把包内日志替换为 recordLogger
+func captureLogs(fn func()) *recordLogger {
+	l := &recordLogger{}
+	prev := logger
+	SetLogger(l)
+	defer SetLogger(prev)
+	fn()
+	return l
+}
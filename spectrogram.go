@@ -0,0 +1,100 @@
+package silk
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"math/cmplx"
+)
+
+// SpectrogramOptions DecodeSpectrogram 的参数
+type SpectrogramOptions struct {
+	Decode     DecodeOptions // 解码参数
+	WindowSize int           // 每帧 FFT 的采样数, 必须是 2 的幂, 为 0 时使用 512
+	Hop        int           // 相邻两帧起点的间隔采样数, 为 0 时为 WindowSize/2
+}
+
+func (o SpectrogramOptions) withDefaults() SpectrogramOptions {
+	o.Decode = o.Decode.withDefaults()
+	if o.WindowSize <= 0 {
+		o.WindowSize = 512
+	}
+	if o.Hop <= 0 {
+		o.Hop = o.WindowSize / 2
+	}
+	return o
+}
+
+// Spectrogram 幅度谱, Data[i][j] 为第 i 帧第 j 个频点的幅度
+type Spectrogram struct {
+	Data  [][]float32
+	Times []float64 // 每帧中心的时间(秒)
+	Freqs []float64 // 每个频点的频率(Hz), 共 WindowSize/2+1 个
+}
+
+// DecodeSpectrogram 解码 src 并计算加 Hann 窗的短时傅里叶变换幅度谱, 用于语音消息的预览图
+func DecodeSpectrogram(src io.Reader, opts SpectrogramOptions) (Spectrogram, error) {
+	opts = opts.withDefaults()
+	n := opts.WindowSize
+	if n&(n-1) != 0 {
+		return Spectrogram{}, fmt.Errorf("spectrogram window size must be a power of 2, got %d", n)
+	}
+	if err := opts.Decode.requireLinear(); err != nil {
+		return Spectrogram{}, err
+	}
+	pcm, info, err := newDecoder().DecodeWithInfo(src, opts.Decode)
+	if err != nil {
+		return Spectrogram{}, err
+	}
+	samples := bytesToSamples(pcm)
+	rate := float64(opts.Decode.decodedRate(info))
+	spec := Spectrogram{Freqs: make([]float64, n/2+1)}
+	for j := range spec.Freqs {
+		spec.Freqs[j] = float64(j) * rate / float64(n)
+	}
+	window := make([]float64, n)
+	for i := range window {
+		window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(n))
+	}
+	frame := make([]complex128, n)
+	for start := 0; start+n <= len(samples); start += opts.Hop {
+		for i := range frame {
+			frame[i] = complex(float64(samples[start+i])/32768*window[i], 0)
+		}
+		fft(frame)
+		row := make([]float32, n/2+1)
+		for j := range row {
+			row[j] = float32(cmplx.Abs(frame[j]))
+		}
+		spec.Data = append(spec.Data, row)
+		spec.Times = append(spec.Times, (float64(start)+float64(n)/2)/rate)
+	}
+	return spec, nil
+}
+
+// fft 原地计算 x 的离散傅里叶变换, len(x) 必须是 2 的幂(迭代的 radix-2 Cooley-Tukey)
+func fft(x []complex128) {
+	n := len(x)
+	// 按位反转的顺序重排
+	for i, j := 1, 0; i < n; i++ {
+		bit := n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+		j |= bit
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+	for size := 2; size <= n; size <<= 1 {
+		step := cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+		for start := 0; start < n; start += size {
+			w := complex(1, 0)
+			for k := 0; k < size/2; k++ {
+				a, b := x[start+k], w*x[start+k+size/2]
+				x[start+k], x[start+k+size/2] = a+b, a-b
+				w *= step
+			}
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package silk
+
+import (
+	"bytes"
+	"math"
+	"math/cmplx"
+	"testing"
+)
+
+func TestFFTMatchesDFT(t *testing.T) {
+	const n = 64
+	x := make([]complex128, n)
+	for i := range x {
+		x[i] = complex(math.Sin(float64(i)*0.7)+0.3*math.Cos(float64(i)*2.1), 0)
+	}
+	want := make([]complex128, n)
+	for k := range want {
+		for i, v := range x {
+			want[k] += v * cmplx.Exp(complex(0, -2*math.Pi*float64(k*i)/n))
+		}
+	}
+	got := append([]complex128(nil), x...)
+	fft(got)
+	for k := range want {
+		if cmplx.Abs(got[k]-want[k]) > 1e-9 {
+			t.Fatalf("bin %d = %v, want %v", k, got[k], want[k])
+		}
+	}
+}
+
+func TestFFTImpulse(t *testing.T) {
+	x := make([]complex128, 16)
+	x[0] = 1
+	fft(x)
+	for k, v := range x {
+		if cmplx.Abs(v-1) > 1e-12 {
+			t.Fatalf("bin %d = %v, want 1 for an impulse", k, v)
+		}
+	}
+}
+
+func TestDecodeSpectrogramPureTone(t *testing.T) {
+	const rate = 16000
+	// 1000 Hz 正好落在 512 点 FFT 的第 32 个频点
+	f := samplesNative(sineSamples(rate/2, 1000, 10000, rate))
+	useFake(t, f)
+	stream := withFooter(buildStream(nil, payloads(25, 30)...))
+	spec, err := DecodeSpectrogram(bytes.NewReader(stream), SpectrogramOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.checkLeaks(t)
+	if len(spec.Freqs) != 257 || spec.Freqs[32] != 1000 {
+		t.Fatalf("%d freqs, bin 32 = %v Hz", len(spec.Freqs), spec.Freqs[32])
+	}
+	// 8000 个采样, 窗长 512, 间隔 256
+	if want := (8000-512)/256 + 1; len(spec.Data) != want || len(spec.Times) != want {
+		t.Fatalf("%d frames (%d times), want %d", len(spec.Data), len(spec.Times), want)
+	}
+	for i, frame := range spec.Data {
+		peak := 0
+		for j, v := range frame {
+			if v > frame[peak] {
+				peak = j
+			}
+		}
+		if peak != 32 {
+			t.Fatalf("frame %d peaks at bin %d (%v Hz), want 32", i, peak, spec.Freqs[peak])
+		}
+	}
+}
+
+func TestDecodeSpectrogramWindowSize(t *testing.T) {
+	useFake(t, newFakeNative())
+	stream := withFooter(buildStream(nil, payloads(2, 30)...))
+	if _, err := DecodeSpectrogram(bytes.NewReader(stream), SpectrogramOptions{WindowSize: 500}); err == nil {
+		t.Fatal("accepted a window size that is not a power of 2")
+	}
+}
@@ -0,0 +1,49 @@
+package silk
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+)
+
+// DecodeReader 按 opts 解码, 返回 pcm 的 reader, 使用完后需要 Close
+// opts.SpillToDisk 开启时边解码边写入 opts.SpillDir 下的临时文件, 不在内存中保留 pcm,
+// Close 时删除该文件; 此时与 DecodeStream 一样不支持 Speed/ResampleTo 等整段选项, 设置时返回 ErrWholeStreamOption
+func (s *silk) DecodeReader(src io.Reader, opts DecodeOptions) (io.ReadCloser, error) {
+	if !opts.SpillToDisk {
+		pcm, err := s.DecodeWithOptions(src, opts)
+		if err != nil {
+			return nil, err
+		}
+		return io.NopCloser(bytes.NewReader(pcm)), nil
+	}
+	f, err := os.CreateTemp(opts.SpillDir, "silk-*.pcm")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create spill file: %w", err)
+	}
+	spill := &spillFile{f}
+	_, err = s.DecodeStreamInfo(context.Background(), f, src, opts)
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		spill.Close()
+		return nil, err
+	}
+	return spill, nil
+}
+
+// spillFile 关闭时删除的临时文件
+type spillFile struct {
+	*os.File
+}
+
+func (f *spillFile) Close() error {
+	err := f.File.Close()
+	if rmErr := os.Remove(f.Name()); err == nil {
+		err = rmErr
+	}
+	return err
+}
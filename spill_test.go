@@ -0,0 +1,68 @@
+package silk
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestDecodeReaderSpillToDisk(t *testing.T) {
+	dir := t.TempDir()
+	f := newFakeNative()
+	r, err := fakeDecoder(f).DecodeReader(bytes.NewReader(buildStream(nil, payloads(3, 4)...)), DecodeOptions{SpillToDisk: true, SpillDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.checkLeaks(t)
+	if got := dirEntries(t, dir); got == "" || strings.Contains(got, " ") {
+		t.Fatalf("spill dir holds %q, want one file", got)
+	}
+	pcm, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pcm) != 3*640 || !equalSamples(firstSamples(pcm, 640), wantFrames(3)) {
+		t.Fatalf("%d pcm bytes, frames %v", len(pcm), firstSamples(pcm, 640))
+	}
+	if err = r.Close(); err != nil {
+		t.Fatal(err)
+	}
+	// Close 后删除临时文件
+	if got := dirEntries(t, dir); got != "" {
+		t.Fatalf("spill dir holds %q after Close", got)
+	}
+}
+
+func TestDecodeReaderSpillToDiskError(t *testing.T) {
+	errDecode := errors.New("decode failed")
+	f := newFakeNative()
+	f.decodeFn = func(call int, in, out []byte, rate int) (int, error) {
+		if call == 2 {
+			return 0, errDecode
+		}
+		return fakeFrame(in, out, rate), nil
+	}
+	stream := buildStream(nil, payloads(3, 4)...)
+	for _, tt := range []struct {
+		name string
+		opts DecodeOptions
+		want error
+	}{
+		{"decode error", DecodeOptions{}, errDecode},
+		{"whole stream option", DecodeOptions{Speed: 2}, ErrWholeStreamOption},
+	} {
+		dir := t.TempDir()
+		tt.opts.SpillToDisk, tt.opts.SpillDir = true, dir
+		r, err := fakeDecoder(f).DecodeReader(bytes.NewReader(stream), tt.opts)
+		if !errors.Is(err, tt.want) || r != nil {
+			t.Fatalf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+		// 失败时不留下临时文件
+		if got := dirEntries(t, dir); got != "" {
+			t.Fatalf("%s: spill dir holds %q", tt.name, got)
+		}
+	}
+	f.checkLeaks(t)
+}
@@ -0,0 +1,133 @@
+package silk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// SplitStreams 将多个 #!SILK_V3 流拼接而成的数据拆分为各个流的原始字节, 不解码
+//
+// 每个流从可选的 STX 和文件头开始, 到下一个文件头或数据末尾结束; footer(长度为负的前缀)
+// 及其之后到下一个文件头之前的数据都包含在该流内. 长度前缀按标准的小端序解析.
+// 返回的切片共用同一块读出的数据, 修改其中一个会影响源数据.
+func SplitStreams(src io.Reader) ([][]byte, error) {
+	data, err := io.ReadAll(src)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read streams: %w", err)
+	}
+	var streams [][]byte
+	for pos := 0; pos < len(data); {
+		end, err := streamEnd(data, pos)
+		if err != nil {
+			return streams, fmt.Errorf("stream %d at offset %d: %w", len(streams), pos, err)
+		}
+		streams = append(streams, data[pos:end])
+		pos = end
+	}
+	return streams, nil
+}
+
+// DecodeAll 解码多个 #!SILK_V3 流拼接而成的数据, 依次输出各流的 pcm
+//
+// 每个流都重新创建解码器并调用 setSampleRate, 解码器状态不会跨流延续.
+// 各流编码时的内部采样率(8k~24k)可以不同, dll 通常会统一转换为 opts.SampleRate 输出;
+// 每个流分别检测 dll 实际输出的采样率, 与第一个流不同时转换为第一个流的采样率(见 DecodePlaylist).
+// 同一个流内假定内部采样率不变. opts.GapBetween/Crossfade 对流之间的拼接同样有效.
+func DecodeAll(src io.Reader, opts DecodeOptions) ([]byte, error) {
+	streams, err := SplitStreams(src)
+	if err != nil {
+		return nil, err
+	}
+	readers := make([]io.Reader, len(streams))
+	for i, stream := range streams {
+		readers[i] = bytes.NewReader(stream)
+	}
+	return DecodePlaylist(readers, opts)
+}
+
+// streamEnd 返回从 start 开始的流的结束位置(不含)
+func streamEnd(data []byte, start int) (int, error) {
+	pos := start
+	if pos < len(data) && data[pos] == STX {
+		pos++
+	}
+	if !bytes.HasPrefix(data[pos:], []byte(Header)) {
+		return 0, fmt.Errorf("%w, expected=%q", ErrInvalidHeader, Header)
+	}
+	pos += HeaderLen
+	for pos < len(data) {
+		// "#!" 作为长度前缀是 8483, 不可能是真实的 block, 所以在 block 边界检查文件头不会误判
+		if hasStreamHeader(data[pos:]) {
+			return pos, nil
+		}
+		if len(data)-pos < 2 {
+			return 0, fmt.Errorf("%w: %d trailing byte at offset %d", ErrTruncatedStream, len(data)-pos, pos)
+		}
+		nByte := int(int16(binary.LittleEndian.Uint16(data[pos:])))
+		pos += 2
+		if nByte < 0 {
+			// footer 之后的填充或元数据归入当前流, 由解码时的 DecodeOptions.Trailer 处理
+			if next := nextStreamHeader(data, pos); next >= 0 {
+				return next, nil
+			}
+			return len(data), nil
+		}
+		if nByte > len(data)-pos {
+			return 0, fmt.Errorf("%w: block at offset %d declares %d bytes, got %d", ErrTruncatedStream, pos-2, nByte, len(data)-pos)
+		}
+		pos += nByte
+	}
+	return pos, nil
+}
+
+// nextStreamHeader 返回 pos 之后第一个(可选 STX 加)文件头的位置, 没有时返回 -1
+func nextStreamHeader(data []byte, pos int) int {
+	i := bytes.Index(data[pos:], []byte(Header))
+	if i < 0 {
+		return -1
+	}
+	start := pos + i
+	if start > pos && data[start-1] == STX {
+		start--
+	}
+	return start
+}
+
+// hasStreamHeader 判断 b 是否以(可选 STX 加)文件头开始
+func hasStreamHeader(b []byte) bool {
+	if len(b) > 0 && b[0] == STX {
+		b = b[1:]
+	}
+	return bytes.HasPrefix(b, []byte(Header))
+}
+
+// DecodeMultipart 将拆分保存的一条语音的各部分依次拼接后按 opts 解码, 返回 pcm 数据
+//
+// parts 按调用方给出的顺序拼接, 包内不解析分片的序号(没有确认过索引头的格式), 需要调用方先排好序.
+// 第一部分必须以(可选 STX 加)文件头开始; 之后的部分是紧接着的 block 数据, 以文件头开始时文件头会被去掉.
+// block 可以跨越分片边界, 解码器对拼接后的整个流只创建一次.
+func DecodeMultipart(parts []io.Reader, opts DecodeOptions) ([]byte, error) {
+	readers := make([]io.Reader, len(parts))
+	for i, part := range parts {
+		if i == 0 {
+			readers[i] = part
+			continue
+		}
+		r := bufio.NewReader(part)
+		head, _ := r.Peek(HeaderLen + 1)
+		if hasStreamHeader(head) {
+			skip := HeaderLen
+			if head[0] == STX {
+				skip++
+			}
+			if _, err := r.Discard(skip); err != nil {
+				return nil, fmt.Errorf("part %d: %w", i, err)
+			}
+		}
+		readers[i] = r
+	}
+	return newDecoder().DecodeWithOptions(io.MultiReader(readers...), opts)
+}
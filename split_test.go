@@ -0,0 +1,186 @@
+package silk
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"testing"
+)
+
+func TestSplitStreams(t *testing.T) {
+	one := withFooter(buildStream(nil, payloads(2, 30)...))
+	two := append([]byte{STX}, buildStream(nil, payloads(3, 20)...)...) // 没有 footer
+	three := append(withFooter(buildStream(nil, payloads(1, 10)...)), "trailer"...)
+	data := append(append(append([]byte(nil), one...), two...), three...)
+	streams, err := SplitStreams(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]byte{one, two, three}
+	if len(streams) != len(want) {
+		t.Fatalf("got %d streams, want %d", len(streams), len(want))
+	}
+	for i := range want {
+		if !bytes.Equal(streams[i], want[i]) {
+			t.Errorf("stream %d = %q, want %q", i, streams[i], want[i])
+		}
+	}
+}
+
+func TestSplitStreamsErrors(t *testing.T) {
+	if _, err := SplitStreams(bytes.NewReader([]byte("not silk"))); !errors.Is(err, ErrInvalidHeader) {
+		t.Errorf("bad header: err = %v, want ErrInvalidHeader", err)
+	}
+	cut := buildStream(nil, payloads(2, 30)...)
+	if _, err := SplitStreams(bytes.NewReader(cut[:len(cut)-5])); !errors.Is(err, ErrTruncatedStream) {
+		t.Errorf("truncated block: err = %v, want ErrTruncatedStream", err)
+	}
+}
+
+func TestDecodeAllMixedRate(t *testing.T) {
+	f := newFakeNative()
+	useFake(t, f)
+	// 第二个流编码时的内部采样率较低, 负载更短; dll 按 setSampleRate 统一输出
+	wideband := withFooter(buildStream(nil, payloads(2, 60)...))
+	narrowband := withFooter(buildStream(nil, payloads(3, 20)...))
+	pcm, err := DecodeAll(bytes.NewReader(append(wideband, narrowband...)), DecodeOptions{SampleRate: 24000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 5 * 960; len(pcm) != want {
+		t.Fatalf("decoded %d bytes, want %d", len(pcm), want)
+	}
+	f.checkLeaks(t)
+	// 每个流各自创建解码器并重新设置采样率
+	if f.created != 2 {
+		t.Fatalf("created %d decoders, want one per stream", f.created)
+	}
+	for handle, rate := range f.rates {
+		if rate != 24000 {
+			t.Errorf("decoder %d configured for %d Hz, want 24000", handle, rate)
+		}
+	}
+	if len(f.rates) != 2 {
+		t.Errorf("setSampleRate called on %d decoders, want 2", len(f.rates))
+	}
+}
+
+// segmentRateFake 第 n 个创建的解码器实际输出 effective[n-1] 采样率的 pcm, 模拟各段输出采样率不同的 dll
+type segmentRateFake struct {
+	*fakeNative
+	effective []int
+}
+
+func (f segmentRateFake) setSampleRate(handle uintptr, sample int) error {
+	return f.fakeNative.setSampleRate(handle, f.effective[handle-1])
+}
+
+func (f segmentRateFake) getSampleRate(handle uintptr) (int, error) {
+	return f.effective[handle-1], nil
+}
+
+func TestDecodeAllPerSegmentRate(t *testing.T) {
+	f := segmentRateFake{newFakeNative(), []int{24000, 16000}}
+	useFake(t, f)
+	first := withFooter(buildStream(nil, payloads(2, 60)...))
+	second := withFooter(buildStream(nil, payloads(3, 20)...))
+	pcm, err := DecodeAll(bytes.NewReader(append(first, second...)), DecodeOptions{SampleRate: 24000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.checkLeaks(t)
+	if f.rates[1] != 24000 || f.rates[2] != 16000 {
+		t.Fatalf("decoder rates %v, want 24000 then 16000", f.rates)
+	}
+	// 第二段 3 帧 @16kHz(960 个采样)转换为 24kHz 的 1440 个采样
+	samples := bytesToSamples(pcm)
+	if len(samples) != 2*480+1440 {
+		t.Fatalf("%d samples, want %d", len(samples), 2*480+1440)
+	}
+	w := wantFrames(3)
+	for i, want := range []int16{w[0], w[1]} {
+		if samples[i*480] != want {
+			t.Fatalf("first stream frame %d = %d, want %d", i, samples[i*480], want)
+		}
+	}
+	// 第二段每帧 20ms 在 24kHz 下仍是 480 个采样, 远离帧边界处为该帧的值
+	for i, want := range w {
+		if got := samples[960+i*480+240]; got != want {
+			t.Fatalf("second stream frame %d = %d, want %d", i, got, want)
+		}
+	}
+}
+
+// multipartParts 把 parts 写成 multipart 请求体再逐个读出, 模拟客户端分片上传
+func multipartParts(t *testing.T, parts ...[]byte) []io.Reader {
+	t.Helper()
+	var body bytes.Buffer
+	w := multipart.NewWriter(&body)
+	for i, p := range parts {
+		fw, err := w.CreateFormFile("part", fmt.Sprintf("voice.%d.silk", i))
+		if err != nil {
+			t.Fatal(err)
+		}
+		fw.Write(p)
+	}
+	w.Close()
+	r := multipart.NewReader(&body, w.Boundary())
+	var readers []io.Reader
+	for {
+		part, err := r.NextPart()
+		if err == io.EOF {
+			return readers
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := io.ReadAll(part)
+		if err != nil {
+			t.Fatal(err)
+		}
+		readers = append(readers, bytes.NewReader(data))
+	}
+}
+
+func TestDecodeMultipart(t *testing.T) {
+	f := newFakeNative()
+	useFake(t, f)
+	frames := payloads(3, 30)
+	first := buildStream(nil, frames[:2]...)
+	// 第二个 block 跨越前两部分的边界, 第三部分带有 STX 和文件头
+	cut := HeaderLen + 2 + 30 + 5
+	third := withFooter(append([]byte{STX}, buildStream(nil, frames[2])...))
+	pcm, err := DecodeMultipart(multipartParts(t, first[:cut], first[cut:], third), DecodeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.checkLeaks(t)
+	if f.created != 1 {
+		t.Fatalf("created %d decoders, want 1 for the joined stream", f.created)
+	}
+	if !equalSamples(firstSamples(pcm, 640), wantFrames(3)) {
+		t.Fatalf("frames %v, want %v", firstSamples(pcm, 640), wantFrames(3))
+	}
+}
+
+func TestDecodeMultipartBadPart(t *testing.T) {
+	f := newFakeNative()
+	useFake(t, f)
+	good := buildStream(nil, payloads(2, 30)...)
+	for _, tt := range []struct {
+		name  string
+		parts [][]byte
+		want  error
+	}{
+		// 第二部分在 block 中间结束
+		{"truncated part", [][]byte{good, buildStream(nil, payloads(1, 30)...)[:HeaderLen+12]}, ErrTruncatedStream},
+		{"first part without header", [][]byte{good[HeaderLen:], good}, ErrInvalidHeader},
+	} {
+		if _, err := DecodeMultipart(multipartParts(t, tt.parts...), DecodeOptions{}); !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+	f.checkLeaks(t)
+}
@@ -0,0 +1,85 @@
+package silk
+
+import "math"
+
+// timeStretch 使用 WSOLA(波形相似叠加)变速不变调, 输出采样数为 len(in)/speed
+//
+// 以 20ms 汉宁窗、50% 重叠合成, 每个分析窗在名义位置 ±5ms 内搜索与上一窗自然延续
+// 最相似(互相关最大)的位置, 以减少相位不连续. 只是基础质量的实现, 适合语音快速回放.
+func timeStretch(in []int16, speed float64, sampleRate int) []int16 {
+	outLen := int(float64(len(in)) / speed)
+	winLen := sampleRate * 20 / 1000
+	hop := winLen / 2
+	delta := sampleRate * 5 / 1000
+	if winLen < 4 || len(in) < winLen+delta {
+		return resampleLength(in, outLen)
+	}
+	window := make([]float64, winLen)
+	for i := range window {
+		window[i] = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(winLen))
+	}
+	acc := make([]float64, outLen+winLen)
+	norm := make([]float64, outLen+winLen)
+	prev := 0
+	for k := 0; k*hop < outLen; k++ {
+		pos := 0
+		if k > 0 {
+			pos = bestOverlap(in, prev+hop, int(math.Round(float64(k*hop)*speed)), delta, hop, winLen)
+			if pos < 0 {
+				// 搜索范围超出输入末尾, 用最后一个完整的窗口填满剩余的输出, 而不是留下静音
+				pos = len(in) - winLen
+			}
+		}
+		for i, w := range window {
+			acc[k*hop+i] += float64(in[pos+i]) * w
+			norm[k*hop+i] += w
+		}
+		prev = pos
+	}
+	out := make([]int16, outLen)
+	for i := range out {
+		if norm[i] > 1e-6 {
+			out[i] = clip16(acc[i] / norm[i])
+		}
+	}
+	return out
+}
+
+// bestOverlap 在 [target-delta, target+delta] 内查找与 in[natural:natural+overlap] 最相似的窗口起点
+// 窗口超出输入时返回 -1
+func bestOverlap(in []int16, natural, target, delta, overlap, winLen int) int {
+	lo, hi := target-delta, target+delta
+	if lo < 0 {
+		lo = 0
+	}
+	if hi > len(in)-winLen {
+		hi = len(in) - winLen
+	}
+	if lo > hi || natural+overlap > len(in) {
+		return -1
+	}
+	best, bestCorr := lo, math.Inf(-1)
+	for pos := lo; pos <= hi; pos++ {
+		var corr float64
+		for i := 0; i < overlap; i++ {
+			corr += float64(in[natural+i]) * float64(in[pos+i])
+		}
+		if corr > bestCorr {
+			best, bestCorr = pos, corr
+		}
+	}
+	return best
+}
+
+// resampleLength 线性插值到 n 个采样, 用于过短无法分窗的输入
+func resampleLength(in []int16, n int) []int16 {
+	out := make([]int16, n)
+	if len(in) == 0 {
+		return out
+	}
+	step := float64(len(in)) / float64(n)
+	for i := range out {
+		out[i] = in[int(float64(i)*step)]
+	}
+	return out
+}
@@ -0,0 +1,105 @@
+package silk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math"
+	"testing"
+)
+
+// sineSamples 生成 n 个频率为 freq、幅度为 amp 的正弦采样
+func sineSamples(n int, freq float64, amp float64, rate int) []int16 {
+	out := make([]int16, n)
+	for i := range out {
+		out[i] = int16(amp * math.Sin(2*math.Pi*freq*float64(i)/float64(rate)))
+	}
+	return out
+}
+
+// zeroCrossings 返回 s 中由负变为非负的次数
+func zeroCrossings(s []int16) int {
+	var n int
+	for i := 1; i < len(s); i++ {
+		if s[i-1] < 0 && s[i] >= 0 {
+			n++
+		}
+	}
+	return n
+}
+
+func TestTimeStretchLength(t *testing.T) {
+	in := sineSamples(16000, 220, 10000, 16000)
+	for _, speed := range []float64{0.5, 0.75, 1.25, 1.5, 2, 3} {
+		out := timeStretch(in, speed, 16000)
+		if want := int(float64(len(in)) / speed); len(out) != want {
+			t.Errorf("speed %v: %d samples, want %d", speed, len(out), want)
+		}
+	}
+}
+
+func TestTimeStretchPreservesPitch(t *testing.T) {
+	const rate = 16000
+	in := sineSamples(rate, 200, 10000, rate) // 1 秒 200 Hz
+	for _, speed := range []float64{1.5, 2} {
+		out := timeStretch(in, speed, rate)
+		// 按输出时长换算每秒的过零次数, 变速不变调时仍约为 200
+		perSecond := float64(zeroCrossings(out)) * rate / float64(len(out))
+		if math.Abs(perSecond-200) > 20 {
+			t.Errorf("speed %v: %.0f crossings per second, want about 200", speed, perSecond)
+		}
+	}
+}
+
+func TestTimeStretchShortInput(t *testing.T) {
+	in := sineSamples(100, 200, 10000, 16000)
+	if out := timeStretch(in, 2, 16000); len(out) != 50 {
+		t.Fatalf("%d samples, want 50", len(out))
+	}
+}
+
+func TestDecodeSpeed(t *testing.T) {
+	stream := withFooter(buildStream(nil, payloads(50, 30)...))
+	normal, _, err := decodeFake(t, stream, DecodeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fast, _, err := decodeFake(t, stream, DecodeOptions{Speed: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := len(normal) / 2; len(fast) != want {
+		t.Fatalf("Speed 2 gave %d bytes, want %d", len(fast), want)
+	}
+}
+
+func TestDecodeStreamRejectsSpeed(t *testing.T) {
+	stream := withFooter(buildStream(nil, payloads(3, 30)...))
+	f := newFakeNative()
+	_, err := fakeDecoder(f).DecodeStreamInfo(context.Background(), io.Discard, bytes.NewReader(stream), DecodeOptions{Speed: 1.5})
+	if !errors.Is(err, ErrWholeStreamOption) {
+		t.Fatalf("err = %v, want ErrWholeStreamOption", err)
+	}
+	f.checkLeaks(t)
+}
+
+func TestTimeStretchFillsTail(t *testing.T) {
+	const rate = 16000
+	// 长度不是窗口移动量的整数倍, 慢放时最后几个窗口的搜索范围超出输入末尾
+	in := make([]int16, 4003)
+	for i := range in {
+		in[i] = 1000
+	}
+	for _, speed := range []float64{0.5, 0.8, 1.25, 2} {
+		out := timeStretch(in, speed, rate)
+		if want := int(float64(len(in)) / speed); len(out) != want {
+			t.Fatalf("speed %v: %d samples, want %d", speed, len(out), want)
+		}
+		for i := len(out) - rate*20/1000; i < len(out); i++ {
+			if out[i] < 900 || out[i] > 1100 {
+				t.Fatalf("speed %v: sample %d of %d = %d, want the input level 1000", speed, i, len(out), out[i])
+			}
+		}
+	}
+}
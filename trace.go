@@ -0,0 +1,51 @@
+package silk
+
+import "errors"
+
+// traceNative 记录每次底层调用的参数和结果, 见 DecodeOptions.TraceProcs
+// 只记录 handle 和数据长度, 不记录音频内容
+type traceNative struct {
+	native
+	logger Logger
+}
+
+func (t traceNative) createDecoder() (uintptr, error) {
+	handle, err := t.native.createDecoder()
+	t.logger.Debug("CreateDecoder() = %#x, err=%v", handle, err)
+	return handle, err
+}
+
+func (t traceNative) closeDecoder(handle uintptr) error {
+	err := t.native.closeDecoder(handle)
+	t.logger.Debug("CloseDecoder(%#x) err=%v", handle, err)
+	return err
+}
+
+func (t traceNative) setSampleRate(handle uintptr, sample int) error {
+	err := t.native.setSampleRate(handle, sample)
+	t.logger.Debug("setSampleRate(%#x, %d) err=%v", handle, sample, err)
+	return err
+}
+
+func (t traceNative) setFramesPerPacket(handle uintptr, perPacket int) error {
+	err := t.native.setFramesPerPacket(handle, perPacket)
+	t.logger.Debug("setFramesPerPacket(%#x, %d) err=%v", handle, perPacket, err)
+	return err
+}
+
+func (t traceNative) decode(handle uintptr, inData []byte, inDataLength int, outData []byte, outDataLength int16) (int, error) {
+	n, err := t.native.decode(handle, inData, inDataLength, outData, outDataLength)
+	t.logger.Debug("Decode(%#x, in=%d bytes, out=%d bytes, outLen=%d) = %d bytes, err=%v",
+		handle, inDataLength, len(outData), outDataLength, n, err)
+	return n, err
+}
+
+func (t traceNative) getSampleRate(handle uintptr) (int, error) {
+	g, ok := t.native.(rateGetter)
+	if !ok {
+		return 0, errors.New("getSampleRate not supported")
+	}
+	rate, err := g.getSampleRate(handle)
+	t.logger.Debug("getSampleRate(%#x) = %d, err=%v", handle, rate, err)
+	return rate, err
+}
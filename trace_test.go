@@ -0,0 +1,49 @@
+package silk
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestTraceProcs(t *testing.T) {
+	payload := []byte("secret-voice")
+	stream := withFooter(buildStream(nil, payload, payload))
+	l := &recordLogger{}
+	if _, _, err := decodeFake(t, stream, DecodeOptions{TraceProcs: true, Logger: l}); err != nil {
+		t.Fatal(err)
+	}
+	var decodes int
+	for _, line := range l.lines {
+		if strings.Contains(line, "Decode(") {
+			decodes++
+			if !strings.Contains(line, fmt.Sprintf("in=%d bytes", len(payload))) {
+				t.Errorf("trace %q does not record the input length", line)
+			}
+		}
+		// 只记录长度, 不记录负载内容
+		for _, form := range []string{string(payload), hex.EncodeToString(payload), fmt.Sprint(payload), fmt.Sprintf("%x", payload[:4])} {
+			if strings.Contains(line, form) {
+				t.Errorf("trace %q contains the payload as %q", line, form)
+			}
+		}
+	}
+	if decodes != 2 {
+		t.Fatalf("traced %d decode calls, want 2: %q", decodes, l.lines)
+	}
+	for _, call := range []string{"CreateDecoder()", "setSampleRate(", "CloseDecoder("} {
+		if !l.contains(call) {
+			t.Errorf("no trace for %s in %q", call, l.lines)
+		}
+	}
+
+	// 未开启时不记录
+	l = &recordLogger{}
+	if _, _, err := decodeFake(t, stream, DecodeOptions{Logger: l}); err != nil {
+		t.Fatal(err)
+	}
+	if l.contains("Decode(") {
+		t.Fatalf("untraced decode logged %q", l.lines)
+	}
+}
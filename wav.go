@@ -0,0 +1,399 @@
+package silk
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+const (
+	// wavHeaderLen RIFF/WAVE 文件头长度
+	wavHeaderLen = 44
+	// wavExtensibleHeaderLen 使用 WAVE_FORMAT_EXTENSIBLE(40 字节 fmt chunk) 时的文件头长度
+	wavExtensibleHeaderLen = 68
+)
+
+// ksdataformatSubtypePCM KSDATAFORMAT_SUBTYPE_PCM {00000001-0000-0010-8000-00AA00389B71}
+var ksdataformatSubtypePCM = [16]byte{0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x80, 0x00, 0x00, 0xAA, 0x00, 0x38, 0x9B, 0x71}
+
+// WavOptions 输出音频(WAV/AIFF)的格式参数
+type WavOptions struct {
+	SampleRate int // 采样率, 为 0 时使用 16000
+	Channels   int // 声道数, 为 0 时为单声道
+	// Extensible 使用 WAVE_FORMAT_EXTENSIBLE 格式(带声道掩码和子格式 GUID), 部分专业软件要求
+	Extensible bool
+	// Info 写在 data chunk 之后的 LIST/INFO 元数据, 键为 4 字节的 id(如 ICMT 注释、ISFT 软件), 只用于 wav.
+	// 播放器会忽略不认识的 chunk; 不是 4 字节的键会被忽略
+	Info map[string]string
+	// UnknownLength 文件头的 RIFF/data 长度写为最大值, 用于无法回填长度的管道(如 os.Stdout)输出;
+	// ffmpeg、sox、VLC 和浏览器会一直读到流结束, 严格校验长度的解析器可能拒绝. 此时不写入 Info
+	UnknownLength bool
+}
+
+func (o WavOptions) withDefaults() WavOptions {
+	if o.SampleRate <= 0 {
+		o.SampleRate = 16000
+	}
+	if o.Channels <= 0 {
+		o.Channels = 1
+	}
+	return o
+}
+
+// headerLen 返回文件头长度
+func (o WavOptions) headerLen() int {
+	if o.Extensible {
+		return wavExtensibleHeaderLen
+	}
+	return wavHeaderLen
+}
+
+// putHeader 在 header[:o.headerLen()] 中填充文件头, RIFF 长度包含 data 之后的 LIST chunk
+func (o WavOptions) putHeader(header []byte, dataLen int) {
+	if o.Extensible {
+		putExtensibleWavHeader(header, dataLen, o.Channels, o.SampleRate)
+	} else {
+		putWavHeader(header, dataLen, o.Channels, o.SampleRate)
+	}
+	if o.UnknownLength {
+		binary.LittleEndian.PutUint32(header[4:8], math.MaxUint32)
+		binary.LittleEndian.PutUint32(header[o.headerLen()-4:], math.MaxUint32-uint32(o.headerLen()-8))
+		return
+	}
+	if trailer := o.trailerLen(dataLen); trailer > 0 {
+		binary.LittleEndian.PutUint32(header[4:8], uint32(dataLen+o.headerLen()-8+trailer))
+	}
+}
+
+// trailerLen 返回 data chunk 之后的字节数: 奇数长度 data 的填充字节和 LIST chunk
+func (o WavOptions) trailerLen(dataLen int) int {
+	if o.UnknownLength {
+		return 0
+	}
+	info := o.infoChunk()
+	if len(info) == 0 {
+		return 0
+	}
+	return dataLen&1 + len(info)
+}
+
+// trailer 返回写在 pcm 之后的数据, 见 trailerLen
+func (o WavOptions) trailer(dataLen int) []byte {
+	if o.UnknownLength {
+		return nil
+	}
+	info := o.infoChunk()
+	if len(info) == 0 {
+		return nil
+	}
+	if dataLen&1 == 1 {
+		info = append([]byte{0}, info...) // chunk 按 2 字节对齐
+	}
+	return info
+}
+
+// infoChunk 按 id 排序生成 LIST/INFO chunk, 没有元数据时返回 nil
+func (o WavOptions) infoChunk() []byte {
+	ids := make([]string, 0, len(o.Info))
+	for id := range o.Info {
+		if len(id) == 4 {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+	sort.Strings(ids)
+	chunk := []byte("LIST\x00\x00\x00\x00INFO")
+	var size [4]byte
+	for _, id := range ids {
+		value := o.Info[id] + "\x00"
+		chunk = append(chunk, id...)
+		binary.LittleEndian.PutUint32(size[:], uint32(len(value)))
+		chunk = append(chunk, size[:]...)
+		chunk = append(chunk, value...)
+		if len(value)&1 == 1 {
+			chunk = append(chunk, 0)
+		}
+	}
+	binary.LittleEndian.PutUint32(chunk[4:8], uint32(len(chunk)-8))
+	return chunk
+}
+
+// WavLayout wav 文件中各部分的位置, 用于之后原地改写 data chunk
+type WavLayout struct {
+	HeaderSize int // 文件头长度(到 pcm 数据之前)
+	DataOffset int // 第一个 pcm 字节的偏移
+	DataSize   int // pcm 数据长度
+}
+
+// Layout 返回 dataLen 字节 pcm 写为 wav 时的布局
+func (o WavOptions) Layout(dataLen int) WavLayout {
+	headerLen := o.headerLen()
+	return WavLayout{HeaderSize: headerLen, DataOffset: headerLen, DataSize: dataLen}
+}
+
+// PCMToWav 给 16bit pcm 加上 wav 文件头, 同时返回文件布局
+func PCMToWav(pcm []byte, opts WavOptions) ([]byte, WavLayout) {
+	opts = opts.withDefaults()
+	layout := opts.Layout(len(pcm))
+	data := make([]byte, layout.DataOffset, layout.DataOffset+len(pcm))
+	opts.putHeader(data, len(pcm))
+	data = append(data, pcm...)
+	return append(data, opts.trailer(len(pcm))...), layout
+}
+
+// WriteWavHeader 将 dataLen 字节 pcm 数据对应的 WAV 头(44 字节, Extensible 时 68 字节)直接写入 w
+// 用于流式输出, 返回写入的字节数; 设置了 opts.Info 时需要在 pcm 之后再调用 WriteWavTrailer
+func WriteWavHeader(w io.Writer, dataLen int, opts WavOptions) (int, error) {
+	opts = opts.withDefaults()
+	var buf [wavExtensibleHeaderLen]byte
+	header := buf[:opts.headerLen()]
+	opts.putHeader(header, dataLen)
+	return w.Write(header)
+}
+
+// WriteWavTrailer 在 dataLen 字节 pcm 之后写入 opts.Info 对应的 LIST chunk, 没有元数据时不写入
+func WriteWavTrailer(w io.Writer, dataLen int, opts WavOptions) (int, error) {
+	if trailer := opts.trailer(dataLen); len(trailer) > 0 {
+		return w.Write(trailer)
+	}
+	return 0, nil
+}
+
+// putWavHeader 在 header[:44] 中填充 16bit pcm 的 RIFF/WAVE 文件头
+func putWavHeader(header []byte, dataLen int, numchannel int, samplerate int) {
+	blockAlign := numchannel * 16 / 8
+	// RIFF/WAVE header
+	copy(header[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:8], uint32(dataLen+36))
+	copy(header[8:12], "WAVE")
+	// 'fmt ' chunk
+	copy(header[12:16], "fmt ")
+	binary.LittleEndian.PutUint32(header[16:20], 16) // size of 'fmt ' chunk
+	binary.LittleEndian.PutUint16(header[20:22], 1)  // format = 1 (PCM)
+	binary.LittleEndian.PutUint16(header[22:24], uint16(numchannel))
+	binary.LittleEndian.PutUint32(header[24:28], uint32(samplerate))
+	binary.LittleEndian.PutUint32(header[28:32], uint32(samplerate*blockAlign)) // byte rate
+	binary.LittleEndian.PutUint16(header[32:34], uint16(blockAlign))
+	binary.LittleEndian.PutUint16(header[34:36], 16) // bits per sample
+	// data
+	copy(header[36:40], "data")
+	binary.LittleEndian.PutUint32(header[40:44], uint32(dataLen))
+}
+
+// putExtensibleWavHeader 在 header[:68] 中填充 WAVE_FORMAT_EXTENSIBLE 格式的文件头
+func putExtensibleWavHeader(header []byte, dataLen int, numchannel int, samplerate int) {
+	blockAlign := numchannel * 16 / 8
+	copy(header[0:4], "RIFF")
+	binary.LittleEndian.PutUint32(header[4:8], uint32(dataLen+wavExtensibleHeaderLen-8))
+	copy(header[8:12], "WAVE")
+	// 'fmt ' chunk
+	copy(header[12:16], "fmt ")
+	binary.LittleEndian.PutUint32(header[16:20], 40)     // size of 'fmt ' chunk
+	binary.LittleEndian.PutUint16(header[20:22], 0xFFFE) // WAVE_FORMAT_EXTENSIBLE
+	binary.LittleEndian.PutUint16(header[22:24], uint16(numchannel))
+	binary.LittleEndian.PutUint32(header[24:28], uint32(samplerate))
+	binary.LittleEndian.PutUint32(header[28:32], uint32(samplerate*blockAlign)) // byte rate
+	binary.LittleEndian.PutUint16(header[32:34], uint16(blockAlign))
+	binary.LittleEndian.PutUint16(header[34:36], 16) // bits per sample
+	binary.LittleEndian.PutUint16(header[36:38], 22) // cbSize
+	binary.LittleEndian.PutUint16(header[38:40], 16) // valid bits per sample
+	binary.LittleEndian.PutUint32(header[40:44], channelMask(numchannel))
+	copy(header[44:60], ksdataformatSubtypePCM[:])
+	// data
+	copy(header[60:64], "data")
+	binary.LittleEndian.PutUint32(header[64:68], uint32(dataLen))
+}
+
+// channelMask 返回常见声道数对应的 dwChannelMask, 未知的声道数为 0(不指定)
+func channelMask(numchannel int) uint32 {
+	switch numchannel {
+	case 1:
+		return 0x4 // SPEAKER_FRONT_CENTER
+	case 2:
+		return 0x3 // FRONT_LEFT | FRONT_RIGHT
+	case 4:
+		return 0x33 // 四声道: 前左右 + 后左右
+	case 6:
+		return 0x3F // 5.1
+	case 8:
+		return 0x63F // 7.1
+	}
+	return 0
+}
+
+// SilkToWavBytes 将silk文件的reader转换为wav数据, 直接返回完整的 []byte
+func SilkToWavBytes(src io.Reader, opts WavOptions) ([]byte, error) {
+	opts = opts.withDefaults()
+	// 预留文件头位置, 解码完成后回填, 避免再拷贝一次 pcm
+	out := bytes.NewBuffer(make([]byte, opts.headerLen()))
+	decoder := newDecoder()
+	info, err := decoder.DecodeStreamInfo(context.Background(), out, src, DecodeOptions{SampleRate: opts.SampleRate})
+	if err != nil {
+		return nil, err
+	}
+	opts.SampleRate = info.EffectiveSampleRate
+	data := out.Bytes()
+	dataLen := len(data) - opts.headerLen()
+	opts.putHeader(data, dataLen)
+	return append(data, opts.trailer(dataLen)...), nil
+}
+
+// DecodeBoth 按 opts 解码一次, 同时返回 wav 文件和其中的 pcm, 用于同时需要播放和处理的场景
+// pcm 与 wav 共用内存(pcm 即 wav 文件头之后的部分), 修改其中一个会影响另一个, 需要分别修改时先复制.
+// wav 按 16bit pcm 写文件头, 因此不支持 Companding
+func (s *silk) DecodeBoth(src io.Reader, opts DecodeOptions) (wav []byte, pcm []byte, info DecodeInfo, err error) {
+	return s.decodeBoth(src, opts, s.WavOptions())
+}
+
+// decodeBoth 同 DecodeBoth, wav 按 wavOpts 写出; wavOpts.Info 的 LIST chunk 追加在共用的 pcm 之后,
+// 返回的 pcm 容量截止到 data chunk 末尾, append 不会覆盖它
+func (s *silk) decodeBoth(src io.Reader, opts DecodeOptions, wavOpts WavOptions) (wav []byte, pcm []byte, info DecodeInfo, err error) {
+	if err = opts.requireLinear(); err != nil {
+		return nil, nil, info, err
+	}
+	reserve := wavOpts.headerLen()
+	wav, info, err = s.decodeReserved(src, opts, reserve)
+	if err != nil {
+		return nil, nil, info, err
+	}
+	wavOpts.SampleRate = opts.decodedRate(info)
+	dataLen := len(wav) - reserve
+	wavOpts.putHeader(wav, dataLen)
+	wav = append(wav, wavOpts.trailer(dataLen)...)
+	return wav, wav[reserve : reserve+dataLen : reserve+dataLen], info, nil
+}
+
+// EncodeWavTo 将 src 边解码边以 wav 写入 ws, 不在内存中保留 pcm
+// 先写入长度为 0 的文件头, 解码结束后 seek 回 RIFF 和 data 的长度字段回填实际大小, 最后回到数据末尾
+func EncodeWavTo(ws io.WriteSeeker, src io.Reader, opts WavOptions) error {
+	opts = opts.withDefaults()
+	start, err := ws.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	if _, err = WriteWavHeader(ws, 0, opts); err != nil {
+		return err
+	}
+	cw := &countingWriter{w: ws}
+	info, err := newDecoder().DecodeStreamInfo(context.Background(), cw, src, DecodeOptions{SampleRate: opts.SampleRate})
+	if err != nil {
+		return err
+	}
+	// 仍在数据末尾, 先写入 LIST chunk; 是否需要填充字节只取决于长度的奇偶
+	trailer := opts.trailer(int(cw.n & 1))
+	if _, err = ws.Write(trailer); err != nil {
+		return err
+	}
+	headerLen := opts.headerLen()
+	if cw.n > math.MaxUint32-int64(headerLen+len(trailer)) {
+		return fmt.Errorf("pcm data too large for wav: %d bytes", cw.n)
+	}
+	var size [4]byte
+	patch := func(offset int64, value uint32) error {
+		if _, err := ws.Seek(start+offset, io.SeekStart); err != nil {
+			return err
+		}
+		binary.LittleEndian.PutUint32(size[:], value)
+		_, err := ws.Write(size[:])
+		return err
+	}
+	if err = patch(4, uint32(cw.n)+uint32(headerLen-8+len(trailer))); err != nil {
+		return err
+	}
+	if err = patch(int64(headerLen-4), uint32(cw.n)); err != nil {
+		return err
+	}
+	if rate := info.EffectiveSampleRate; rate != opts.SampleRate {
+		// 文件头中的采样率和字节率在两种格式下位置相同
+		if err = patch(24, uint32(rate)); err != nil {
+			return err
+		}
+		if err = patch(28, uint32(rate*opts.Channels*2)); err != nil {
+			return err
+		}
+	}
+	_, err = ws.Seek(start+int64(headerLen)+cw.n+int64(len(trailer)), io.SeekStart)
+	return err
+}
+
+// WavReaderFrom 返回按需解码的 wav reader: 先读出文件头, 之后每次 Read 时才继续解码, 内存占用与输入长度无关,
+// 可以直接用于 io.Copy. 长度在解码前未知, 总是按 UnknownLength 写文件头;
+// 需要准确长度或 opts.Info 且目标可以 seek 时使用 EncodeWavTo. 文件头错误会立即返回, 之后的错误由 Read 返回.
+// 提前停止读取时需要 Close, 否则后台解码会一直等待
+func WavReaderFrom(src io.Reader, opts WavOptions) (io.ReadCloser, error) {
+	opts = opts.withDefaults()
+	opts.UnknownLength = true
+	br := bufio.NewReader(src)
+	// 按解码时相同的规则检查文件头, 需要看到完整的前缀, 否则 AMR-WB 包装的 silk 会被误判
+	if head, _ := br.Peek(maxHeaderPrefix); !hasStreamHeader(head) {
+		if err := checkHeader(bufio.NewReader(bytes.NewReader(head)), 0, logger); err != nil {
+			return nil, err
+		}
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		// 文件头在 dll 实际输出的采样率确定之后、第一帧之前写出
+		w := &lazyWavWriter{w: pw, opts: opts}
+		decodeOpts := DecodeOptions{SampleRate: opts.SampleRate}
+		decodeOpts.rateDetected = func(rate int) { w.opts.SampleRate = rate }
+		err := newDecoder().DecodeStream(w, br, decodeOpts)
+		if err == nil && !w.started {
+			err = w.start()
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr, nil
+}
+
+// lazyWavWriter 在写入第一帧 pcm 时才写出 wav 文件头
+type lazyWavWriter struct {
+	w       io.Writer
+	opts    WavOptions
+	started bool
+}
+
+func (lw *lazyWavWriter) start() error {
+	lw.started = true
+	var buf [wavExtensibleHeaderLen]byte
+	header := buf[:lw.opts.headerLen()]
+	lw.opts.putHeader(header, 0)
+	_, err := lw.w.Write(header)
+	return err
+}
+
+func (lw *lazyWavWriter) Write(p []byte) (int, error) {
+	if !lw.started {
+		if err := lw.start(); err != nil {
+			return 0, err
+		}
+	}
+	return lw.w.Write(p)
+}
+
+// WriteWav 将 src 解码为 wav 写入 w
+// opts.UnknownLength 时边解码边写出, 适用于管道; 否则先在内存中完成解码, 写出长度准确的文件
+func WriteWav(w io.Writer, src io.Reader, opts WavOptions) error {
+	opts = opts.withDefaults()
+	if !opts.UnknownLength {
+		data, err := SilkToWavBytes(src, opts)
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+	r, err := WavReaderFrom(src, opts)
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+	_, err = io.Copy(w, r)
+	return err
+}
@@ -0,0 +1,522 @@
+package silk
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestWriteWavHeaderMatchesPcmToWav(t *testing.T) {
+	for _, tc := range []struct {
+		dataLen, channels, rate int
+	}{
+		{0, 1, 16000},
+		{640, 1, 24000},
+		{12345, 2, 8000},
+		{1 << 20, 1, 48000},
+	} {
+		var buf bytes.Buffer
+		n, err := WriteWavHeader(&buf, tc.dataLen, WavOptions{SampleRate: tc.rate, Channels: tc.channels})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != wavHeaderLen || buf.Len() != wavHeaderLen {
+			t.Fatalf("wrote %d (%d buffered) bytes, want %d", n, buf.Len(), wavHeaderLen)
+		}
+		want := pcmToWav(make([]byte, tc.dataLen), tc.channels, tc.rate)[:wavHeaderLen]
+		if !bytes.Equal(buf.Bytes(), want) {
+			t.Errorf("%+v: header\n% x\nwant\n% x", tc, buf.Bytes(), want)
+		}
+	}
+}
+
+func TestWriteWavHeaderFields(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := WriteWavHeader(&buf, 1000, WavOptions{SampleRate: 24000, Channels: 2}); err != nil {
+		t.Fatal(err)
+	}
+	h := buf.Bytes()
+	le := binary.LittleEndian
+	for _, f := range []struct {
+		name string
+		got  uint32
+		want uint32
+	}{
+		{"RIFF size", le.Uint32(h[4:8]), 1036},
+		{"fmt size", le.Uint32(h[16:20]), 16},
+		{"format", uint32(le.Uint16(h[20:22])), 1},
+		{"channels", uint32(le.Uint16(h[22:24])), 2},
+		{"sample rate", le.Uint32(h[24:28]), 24000},
+		{"byte rate", le.Uint32(h[28:32]), 96000},
+		{"block align", uint32(le.Uint16(h[32:34])), 4},
+		{"bits", uint32(le.Uint16(h[34:36])), 16},
+		{"data size", le.Uint32(h[40:44]), 1000},
+	} {
+		if f.got != f.want {
+			t.Errorf("%s = %d, want %d", f.name, f.got, f.want)
+		}
+	}
+	if string(h[0:4]) != "RIFF" || string(h[8:12]) != "WAVE" || string(h[12:16]) != "fmt " || string(h[36:40]) != "data" {
+		t.Errorf("chunk ids in % x", h)
+	}
+}
+
+func TestWriteWavHeaderDefaults(t *testing.T) {
+	var buf bytes.Buffer
+	if _, err := WriteWavHeader(&buf, 0, WavOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if want := pcmToWav(nil, 1, 16000); !bytes.Equal(buf.Bytes(), want) {
+		t.Fatalf("default header % x, want % x", buf.Bytes(), want)
+	}
+}
+
+// wavFmt 测试中解析出的 fmt chunk 字段
+type wavFmt struct {
+	format, channels, blockAlign, bits uint16
+	sampleRate, byteRate               uint32
+	cbSize, validBits                  uint16
+	channelMask                        uint32
+	subFormat                          [16]byte
+}
+
+// parseWav 按 chunk 解析 wav, 校验 RIFF 长度, 返回 fmt 字段和 data chunk 的内容
+func parseWav(t *testing.T, data []byte) (wavFmt, []byte) {
+	t.Helper()
+	le := binary.LittleEndian
+	if len(data) < 12 || string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		t.Fatalf("not a RIFF/WAVE file: % x", data[:12])
+	}
+	if size := le.Uint32(data[4:8]); int(size) != len(data)-8 {
+		t.Fatalf("RIFF size = %d, want %d", size, len(data)-8)
+	}
+	var f wavFmt
+	var pcm []byte
+	for rest := data[12:]; len(rest) > 0; {
+		if len(rest) < 8 {
+			t.Fatalf("%d trailing bytes after the last chunk", len(rest))
+		}
+		id, size := string(rest[0:4]), int(le.Uint32(rest[4:8]))
+		if 8+size > len(rest) {
+			t.Fatalf("chunk %q size %d exceeds the remaining %d bytes", id, size, len(rest)-8)
+		}
+		body := rest[8 : 8+size]
+		switch id {
+		case "fmt ":
+			f.format, f.channels = le.Uint16(body[0:2]), le.Uint16(body[2:4])
+			f.sampleRate, f.byteRate = le.Uint32(body[4:8]), le.Uint32(body[8:12])
+			f.blockAlign, f.bits = le.Uint16(body[12:14]), le.Uint16(body[14:16])
+			if size >= 40 {
+				f.cbSize, f.validBits = le.Uint16(body[16:18]), le.Uint16(body[18:20])
+				f.channelMask = le.Uint32(body[20:24])
+				copy(f.subFormat[:], body[24:40])
+			}
+		case "data":
+			pcm = body
+		}
+		rest = rest[8+size+size&1:]
+	}
+	return f, pcm
+}
+
+func TestExtensibleWavHeader(t *testing.T) {
+	for _, tc := range []struct {
+		channels int
+		mask     uint32
+	}{
+		{1, 0x4},
+		{2, 0x3},
+		{6, 0x3F},
+		{3, 0},
+	} {
+		pcm := make([]byte, 12*tc.channels)
+		data, layout := PCMToWav(pcm, WavOptions{SampleRate: 24000, Channels: tc.channels, Extensible: true})
+		if layout.DataOffset != wavExtensibleHeaderLen {
+			t.Fatalf("DataOffset = %d, want %d", layout.DataOffset, wavExtensibleHeaderLen)
+		}
+		if size := binary.LittleEndian.Uint32(data[16:20]); size != 40 {
+			t.Fatalf("fmt chunk size = %d, want 40", size)
+		}
+		f, body := parseWav(t, data)
+		want := wavFmt{
+			format: 0xFFFE, channels: uint16(tc.channels), blockAlign: uint16(2 * tc.channels), bits: 16,
+			sampleRate: 24000, byteRate: uint32(48000 * tc.channels),
+			cbSize: 22, validBits: 16, channelMask: tc.mask, subFormat: ksdataformatSubtypePCM,
+		}
+		if f != want {
+			t.Errorf("%d channels: fmt %+v, want %+v", tc.channels, f, want)
+		}
+		if len(body) != len(pcm) {
+			t.Errorf("%d channels: data has %d bytes, want %d", tc.channels, len(body), len(pcm))
+		}
+	}
+}
+
+func TestWriteWavHeaderExtensible(t *testing.T) {
+	var buf bytes.Buffer
+	n, err := WriteWavHeader(&buf, 100, WavOptions{Extensible: true})
+	if err != nil || n != wavExtensibleHeaderLen {
+		t.Fatalf("WriteWavHeader = %d, %v, want %d bytes", n, err, wavExtensibleHeaderLen)
+	}
+	want, _ := PCMToWav(make([]byte, 100), WavOptions{Extensible: true})
+	if !bytes.Equal(buf.Bytes(), want[:wavExtensibleHeaderLen]) {
+		t.Fatalf("header % x, want % x", buf.Bytes(), want[:wavExtensibleHeaderLen])
+	}
+}
+
+// memWriteSeeker 内存中的 io.WriteSeeker
+type memWriteSeeker struct {
+	buf []byte
+	pos int64
+}
+
+func (m *memWriteSeeker) Write(p []byte) (int, error) {
+	if end := m.pos + int64(len(p)); end > int64(len(m.buf)) {
+		m.buf = append(m.buf, make([]byte, end-int64(len(m.buf)))...)
+	}
+	n := copy(m.buf[m.pos:], p)
+	m.pos += int64(n)
+	return n, nil
+}
+
+func (m *memWriteSeeker) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += m.pos
+	case io.SeekEnd:
+		offset += int64(len(m.buf))
+	}
+	if offset < 0 {
+		return 0, errors.New("negative position")
+	}
+	m.pos = offset
+	return offset, nil
+}
+
+func TestEncodeWavTo(t *testing.T) {
+	useFake(t, newFakeNative())
+	stream := withFooter(buildStream(nil, payloads(5, 30)...))
+	ws := &memWriteSeeker{}
+	if err := EncodeWavTo(ws, bytes.NewReader(stream), WavOptions{SampleRate: 24000}); err != nil {
+		t.Fatal(err)
+	}
+	le := binary.LittleEndian
+	if riff, data := le.Uint32(ws.buf[4:8]), le.Uint32(ws.buf[40:44]); riff != 36+5*960 || data != 5*960 {
+		t.Fatalf("patched RIFF/data sizes = %d/%d, want %d/%d", riff, data, 36+5*960, 5*960)
+	}
+	f, pcm := parseWav(t, ws.buf)
+	if f.sampleRate != 24000 || len(pcm) != 5*960 {
+		t.Fatalf("%d Hz with %d bytes of pcm", f.sampleRate, len(pcm))
+	}
+	if ws.pos != int64(len(ws.buf)) {
+		t.Fatalf("left the writer at %d of %d", ws.pos, len(ws.buf))
+	}
+}
+
+func TestEncodeWavToAtOffset(t *testing.T) {
+	useFake(t, newFakeNative())
+	stream := withFooter(buildStream(nil, payloads(2, 30)...))
+	ws := &memWriteSeeker{}
+	ws.Write([]byte("prefix"))
+	if err := EncodeWavTo(ws, bytes.NewReader(stream), WavOptions{Info: map[string]string{"ICMT": "note"}}); err != nil {
+		t.Fatal(err)
+	}
+	if string(ws.buf[:6]) != "prefix" {
+		t.Fatalf("overwrote data before the start offset: %q", ws.buf[:6])
+	}
+	// 长度按起始位置回填, 并包含 LIST chunk
+	_, pcm := parseWav(t, ws.buf[6:])
+	if len(pcm) != 2*640 {
+		t.Fatalf("data chunk has %d bytes, want %d", len(pcm), 2*640)
+	}
+}
+
+func TestSilkToWavRejectsWav(t *testing.T) {
+	f := newFakeNative()
+	useFake(t, f)
+	wav, _ := PCMToWav(make([]byte, 640), WavOptions{})
+	if _, err := SilkToWav(bytes.NewReader(wav)); !errors.Is(err, ErrAlreadyWav) {
+		t.Fatalf("SilkToWav: err = %v, want ErrAlreadyWav", err)
+	}
+	if _, err := SilkToWavBytes(bytes.NewReader(wav), WavOptions{}); !errors.Is(err, ErrAlreadyWav) {
+		t.Fatalf("SilkToWavBytes: err = %v, want ErrAlreadyWav", err)
+	}
+	f.checkLeaks(t)
+	// 只有 RIFF 没有 WAVE 时仍按无效的文件头处理
+	riff := append([]byte("RIFF\x00\x00\x00\x00AVI "), make([]byte, 16)...)
+	if _, err := SilkToWav(bytes.NewReader(riff)); !errors.Is(err, ErrInvalidHeader) {
+		t.Fatalf("RIFF/AVI: err = %v, want ErrInvalidHeader", err)
+	}
+}
+
+func TestSilkToWav(t *testing.T) {
+	useFake(t, newFakeNative())
+	stream := withFooter(buildStream(nil, payloads(3, 30)...))
+	r, err := SilkToWav(bytes.NewReader(stream))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(r)
+	f, pcm := parseWav(t, data)
+	if f.sampleRate != 16000 || f.channels != 1 || len(pcm) != 3*640 {
+		t.Fatalf("%d Hz, %d channels, %d bytes of pcm", f.sampleRate, f.channels, len(pcm))
+	}
+}
+
+// parseWavInfo 返回 wav 中 LIST/INFO chunk 的所有标签, 值去掉结尾的 NUL
+func parseWavInfo(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	le := binary.LittleEndian
+	tags := make(map[string]string)
+	for rest := data[12:]; len(rest) >= 8; {
+		id, size := string(rest[0:4]), int(le.Uint32(rest[4:8]))
+		if id == "LIST" && string(rest[8:12]) == "INFO" {
+			for sub := rest[12 : 8+size]; len(sub) >= 8; {
+				n := int(le.Uint32(sub[4:8]))
+				tags[string(sub[0:4])] = strings.TrimRight(string(sub[8:8+n]), "\x00")
+				sub = sub[8+n+n&1:]
+			}
+		}
+		rest = rest[8+size+size&1:]
+	}
+	return tags
+}
+
+func TestWavInfoChunk(t *testing.T) {
+	for _, dataLen := range []int{640, 641} { // 奇数长度时 data 之后有填充字节
+		opts := WavOptions{Info: map[string]string{"ICMT": "voice note", "ISFT": "silk", "bad": "ignored"}}
+		wav, layout := PCMToWav(make([]byte, dataLen), opts)
+		_, pcm := parseWav(t, wav)
+		if len(pcm) != dataLen || layout.DataSize != dataLen {
+			t.Fatalf("data chunk has %d bytes (layout %d), want %d", len(pcm), layout.DataSize, dataLen)
+		}
+		tags := parseWavInfo(t, wav)
+		if len(tags) != 2 || tags["ICMT"] != "voice note" || tags["ISFT"] != "silk" {
+			t.Fatalf("INFO tags = %v", tags)
+		}
+	}
+}
+
+func TestWavInfoStreaming(t *testing.T) {
+	opts := WavOptions{Info: map[string]string{"ICMT": "odd"}}
+	pcm := make([]byte, 101)
+	var buf bytes.Buffer
+	if _, err := WriteWavHeader(&buf, len(pcm), opts); err != nil {
+		t.Fatal(err)
+	}
+	buf.Write(pcm)
+	if _, err := WriteWavTrailer(&buf, len(pcm), opts); err != nil {
+		t.Fatal(err)
+	}
+	want, _ := PCMToWav(pcm, opts)
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Fatal("WriteWavHeader + pcm + WriteWavTrailer differs from PCMToWav")
+	}
+	if tags := parseWavInfo(t, buf.Bytes()); tags["ICMT"] != "odd" {
+		t.Fatalf("INFO tags = %v", tags)
+	}
+	if n, err := WriteWavTrailer(&buf, 10, WavOptions{}); n != 0 || err != nil {
+		t.Fatalf("trailer without Info wrote %d bytes, %v", n, err)
+	}
+}
+
+func TestWavReaderFrom(t *testing.T) {
+	f := newFakeNative()
+	useFake(t, f)
+	stream := withFooter(buildStream(nil, payloads(100, 30)...))
+	r, err := WavReaderFrom(bytes.NewReader(stream), WavOptions{SampleRate: 24000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var lazy bytes.Buffer
+	if _, err = io.Copy(&lazy, r); err != nil {
+		t.Fatal(err)
+	}
+	r.Close()
+	eager, err := SilkToWavBytes(bytes.NewReader(stream), WavOptions{SampleRate: 24000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := lazy.Bytes()
+	if len(got) != len(eager) || !bytes.Equal(got[wavHeaderLen:], eager[wavHeaderLen:]) {
+		t.Fatalf("lazy pcm (%d bytes) differs from the eager result (%d bytes)", len(got), len(eager))
+	}
+	// 长度未知, 只有 RIFF/data 的长度字段不同
+	if !bytes.Equal(got[8:40], eager[8:40]) {
+		t.Fatalf("header fields\n% x\nwant\n% x", got[8:40], eager[8:40])
+	}
+	le := binary.LittleEndian
+	if le.Uint32(got[4:8]) != 0xFFFFFFFF || le.Uint32(got[40:44]) != 0xFFFFFFFF-36 {
+		t.Fatalf("RIFF/data sizes = %#x/%#x, want the unknown-length markers", le.Uint32(got[4:8]), le.Uint32(got[40:44]))
+	}
+	f.checkLeaks(t)
+}
+
+func TestWavReaderFromErrors(t *testing.T) {
+	useFake(t, newFakeNative())
+	if _, err := WavReaderFrom(bytes.NewReader([]byte("definitely not silk")), WavOptions{}); !errors.Is(err, ErrInvalidHeader) {
+		t.Fatalf("bad header: err = %v, want ErrInvalidHeader", err)
+	}
+	stream := buildStream(nil, payloads(3, 30)...)
+	r, err := WavReaderFrom(bytes.NewReader(stream[:len(stream)-5]), WavOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	if _, err = io.Copy(io.Discard, r); !errors.Is(err, ErrTruncatedStream) {
+		t.Fatalf("truncated stream: err = %v, want ErrTruncatedStream from Read", err)
+	}
+}
+
+func TestWavReaderFromAMRWBWrapped(t *testing.T) {
+	f := newFakeNative()
+	useFake(t, f)
+	silkStream := withFooter(buildStream(nil, payloads(3, 30)...))
+	for _, prefix := range []string{amrWBMagic, amrWBMagic + "\x02"} {
+		stream := append([]byte(prefix), silkStream...)
+		r, err := WavReaderFrom(bytes.NewReader(stream), WavOptions{})
+		if err != nil {
+			t.Fatalf("%q prefix: rejected before decoding: %v", prefix, err)
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pcm := data[wavHeaderLen:]; !equalSamples(firstSamples(pcm, 640), wantFrames(3)) {
+			t.Fatalf("%q prefix: frames %v, want %v", prefix, firstSamples(pcm, 640), wantFrames(3))
+		}
+	}
+	f.checkLeaks(t)
+
+	// 真正的 AMR-WB 仍在开始解码前拒绝
+	amr := append([]byte(amrWBMagic), 0x3C, 0x48, 0x17, 0x16, 0x80, 0xE0, 0x11, 0x10, 0x00, 0x00)
+	if _, err := WavReaderFrom(bytes.NewReader(amr), WavOptions{}); !errors.Is(err, ErrUnsupportedAMRWB) {
+		t.Fatalf("AMR-WB: err = %v, want ErrUnsupportedAMRWB", err)
+	}
+}
+
+func TestWavReaderFromEffectiveRate(t *testing.T) {
+	useFake(t, rateFake{newFakeNative(), 24000})
+	stream := withFooter(buildStream(nil, payloads(2, 30)...))
+	r, err := WavReaderFrom(bytes.NewReader(stream), WavOptions{SampleRate: 16000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 文件头在 dll 报告实际采样率之后才写出
+	if rate := binary.LittleEndian.Uint32(data[24:28]); rate != 24000 {
+		t.Fatalf("header sample rate = %d, want 24000", rate)
+	}
+}
+
+func TestWavHeaderUnknownLength(t *testing.T) {
+	le := binary.LittleEndian
+	for _, extensible := range []bool{false, true} {
+		opts := WavOptions{UnknownLength: true, Extensible: extensible, Info: map[string]string{"ICMT": "x"}}
+		var buf bytes.Buffer
+		if _, err := WriteWavHeader(&buf, 1234, opts); err != nil {
+			t.Fatal(err)
+		}
+		header := buf.Bytes()
+		n := opts.headerLen()
+		if len(header) != n {
+			t.Fatalf("extensible %v: header %d bytes, want %d", extensible, len(header), n)
+		}
+		if got := le.Uint32(header[4:8]); got != 0xFFFFFFFF {
+			t.Errorf("extensible %v: RIFF size %#x, want 0xFFFFFFFF", extensible, got)
+		}
+		if string(header[n-8:n-4]) != "data" {
+			t.Fatalf("extensible %v: no data chunk at %d", extensible, n-8)
+		}
+		// data 长度加上文件头不能超过 RIFF 声明的大小
+		if got, want := le.Uint32(header[n-4:]), 0xFFFFFFFF-uint32(n-8); got != want {
+			t.Errorf("extensible %v: data size %#x, want %#x", extensible, got, want)
+		}
+		if len(opts.trailer(1234)) != 0 {
+			t.Errorf("extensible %v: UnknownLength wrote a trailer", extensible)
+		}
+	}
+}
+
+func TestWriteWavUnknownLength(t *testing.T) {
+	f := newFakeNative()
+	useFake(t, f)
+	stream := buildStream(nil, payloads(3, 4)...)
+	var out bytes.Buffer
+	if err := WriteWav(&out, bytes.NewReader(stream), WavOptions{UnknownLength: true}); err != nil {
+		t.Fatal(err)
+	}
+	f.checkLeaks(t)
+	data := out.Bytes()
+	if got := binary.LittleEndian.Uint32(data[4:8]); got != 0xFFFFFFFF {
+		t.Fatalf("RIFF size %#x, want 0xFFFFFFFF", got)
+	}
+	if pcm := data[wavHeaderLen:]; len(pcm) != 3*640 || !equalSamples(firstSamples(pcm, 640), wantFrames(3)) {
+		t.Fatalf("pcm after header: %d bytes, frames %v", len(pcm), firstSamples(pcm, 640))
+	}
+}
+
+func TestDecodeBoth(t *testing.T) {
+	f := newFakeNative()
+	stream := withFooter(buildStream(nil, payloads(3, 4)...))
+	wav, pcm, info, err := fakeDecoder(f).DecodeBoth(bytes.NewReader(stream), DecodeOptions{})
+	f.checkLeaks(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(wav[wavHeaderLen:], pcm) || len(pcm) != 3*640 || info.Frames != 3 {
+		t.Fatalf("wav[44:] != pcm: wav %d bytes, pcm %d bytes, %d frames", len(wav), len(pcm), info.Frames)
+	}
+	format, data := parseWav(t, wav)
+	if format.sampleRate != 16000 || !bytes.Equal(data, pcm) {
+		t.Fatalf("wav header rate %d, data %d bytes", format.sampleRate, len(data))
+	}
+	// pcm 与 wav 共用内存, 但 append 不会写入 wav
+	pcm[0] ^= 0xFF
+	if wav[wavHeaderLen] != pcm[0] {
+		t.Fatal("pcm does not share memory with wav")
+	}
+	if cap(pcm) != len(pcm) {
+		t.Fatalf("pcm cap %d > len %d, append could write past the wav", cap(pcm), len(pcm))
+	}
+}
+
+func TestDecodeBothRejectsCompanding(t *testing.T) {
+	f := newFakeNative()
+	_, _, _, err := fakeDecoder(f).DecodeBoth(bytes.NewReader(buildStream(nil, payloads(1, 4)...)), DecodeOptions{Companding: CompandingALaw})
+	if !errors.Is(err, ErrCompandedOutput) {
+		t.Fatalf("err = %v, want ErrCompandedOutput", err)
+	}
+}
+
+func TestDecodeBothInfoTrailer(t *testing.T) {
+	f := newFakeNative()
+	s := fakeDecoder(f)
+	stream := withFooter(buildStream(nil, payloads(3, 4)...))
+	wavOpts := s.WavOptions()
+	wavOpts.Info = map[string]string{"ICMT": "voice note"}
+	wav, pcm, _, err := s.decodeBoth(bytes.NewReader(stream), DecodeOptions{}, wavOpts)
+	f.checkLeaks(t)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// RIFF 长度包含 LIST chunk, 且 LIST chunk 紧跟在共用的 pcm 之后
+	_, data := parseWav(t, wav)
+	if !bytes.Equal(data, pcm) || len(pcm) != 3*640 {
+		t.Fatalf("data chunk %d bytes, pcm %d bytes", len(data), len(pcm))
+	}
+	if trailer := wavOpts.trailer(len(pcm)); len(trailer) == 0 || !bytes.Equal(wav[wavHeaderLen+len(pcm):], trailer) {
+		t.Fatalf("wav ends with % x, want the LIST chunk % x", wav[wavHeaderLen+len(pcm):], trailer)
+	}
+	if cap(pcm) != len(pcm) {
+		t.Fatalf("pcm cap %d > len %d, append could overwrite the LIST chunk", cap(pcm), len(pcm))
+	}
+}
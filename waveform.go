@@ -0,0 +1,45 @@
+package silk
+
+import (
+	"fmt"
+	"io"
+)
+
+// PeakPair 一段采样中的最小值和最大值
+type PeakPair struct {
+	Min, Max int16
+}
+
+// DecodeWaveform 解码 src 并把采样均分为 buckets 段, 返回每段的最小/最大值, 用于绘制波形
+// 采样数少于 buckets 时每个采样一段, 返回的段数等于采样数
+func DecodeWaveform(src io.Reader, buckets int, opts DecodeOptions) ([]PeakPair, error) {
+	if buckets <= 0 {
+		return nil, fmt.Errorf("invalid waveform bucket count: %d", buckets)
+	}
+	if err := opts.requireLinear(); err != nil {
+		return nil, err
+	}
+	pcm, err := newDecoder().DecodeWithOptions(src, opts)
+	if err != nil {
+		return nil, err
+	}
+	samples := bytesToSamples(pcm)
+	if buckets > len(samples) {
+		buckets = len(samples)
+	}
+	peaks := make([]PeakPair, buckets)
+	for i := range peaks {
+		part := samples[i*len(samples)/buckets : (i+1)*len(samples)/buckets]
+		peak := PeakPair{Min: part[0], Max: part[0]}
+		for _, v := range part[1:] {
+			if v < peak.Min {
+				peak.Min = v
+			}
+			if v > peak.Max {
+				peak.Max = v
+			}
+		}
+		peaks[i] = peak
+	}
+	return peaks, nil
+}
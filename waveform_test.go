@@ -0,0 +1,64 @@
+package silk
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestDecodeWaveform(t *testing.T) {
+	useFake(t, levelsNative(100, -200, 300, -400))
+	stream := withFooter(buildStream(nil, payloads(4, 30)...))
+	peaks, err := DecodeWaveform(bytes.NewReader(stream), 2, DecodeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []PeakPair{{-200, 100}, {-400, 300}}
+	if len(peaks) != len(want) || peaks[0] != want[0] || peaks[1] != want[1] {
+		t.Fatalf("peaks = %v, want %v", peaks, want)
+	}
+}
+
+func TestDecodeWaveformBucketBoundaries(t *testing.T) {
+	samples := make([]int16, 320)
+	samples[0], samples[106], samples[107], samples[319] = 1000, -1000, 500, -500
+	useFake(t, samplesNative(samples))
+	stream := withFooter(buildStream(nil, payloads(1, 30)...))
+	// 320 个采样分为 3 段: [0,106) [106,213) [213,320)
+	peaks, err := DecodeWaveform(bytes.NewReader(stream), 3, DecodeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []PeakPair{{0, 1000}, {-1000, 500}, {-500, 0}}
+	for i := range want {
+		if peaks[i] != want[i] {
+			t.Fatalf("peaks = %v, want %v", peaks, want)
+		}
+	}
+}
+
+func TestDecodeWaveformMoreBucketsThanSamples(t *testing.T) {
+	useFake(t, levelsNative(7))
+	stream := withFooter(buildStream(nil, payloads(1, 30)...))
+	peaks, err := DecodeWaveform(bytes.NewReader(stream), 1000, DecodeOptions{SampleRate: 8000})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(peaks) != 160 {
+		t.Fatalf("%d buckets, want one per sample (160)", len(peaks))
+	}
+	for _, p := range peaks {
+		if p != (PeakPair{7, 7}) {
+			t.Fatalf("single-sample bucket %v, want {7 7}", p)
+		}
+	}
+}
+
+func TestDecodeWaveformInvalid(t *testing.T) {
+	useFake(t, newFakeNative())
+	stream := withFooter(buildStream(nil, payloads(1, 30)...))
+	for _, buckets := range []int{0, -1} {
+		if _, err := DecodeWaveform(bytes.NewReader(stream), buckets, DecodeOptions{}); err == nil {
+			t.Fatalf("accepted %d buckets", buckets)
+		}
+	}
+}